@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/koniferous22/dot-user-git-util/utils"
+	"github.com/ogier/pflag"
+)
+
+const (
+	InitCommand          = "init"
+	UpdateCommand        = "update"
+	StatusCommand        = "status"
+	RemoveCommand        = "remove"
+	ListTemplatesCommand = "list-templates"
+	DoctorCommand        = "doctor"
+	HelpCommand          = "help"
+)
+
+// Exit without printing an error, e.g. when user quits the prompt
+var errQuit = errors.New("quit")
+
+type Command struct {
+	Name        string
+	Description string
+	// Positional arguments are git repositories, defaulting to current directory
+	AcceptsRepositories bool
+	RegisterFlags       func(flagSet *pflag.FlagSet, config *Config)
+	Run                 func(appConfig AppConfig) error
+}
+
+func GetCommands() []Command {
+	return []Command{
+		{
+			Name:                InitCommand,
+			Description:         "Initialize or update target directories with picked template entries (default command)",
+			AcceptsRepositories: true,
+			RegisterFlags:       registerInitializationFlags,
+			Run:                 runInitCommand,
+		},
+		{
+			Name:                UpdateCommand,
+			Description:         "Update installed template entries in repositories with existing target directory",
+			AcceptsRepositories: true,
+			RegisterFlags:       registerUpdateFlags,
+			Run:                 runUpdateCommand,
+		},
+		{
+			Name:                StatusCommand,
+			Description:         "Report target directories, .gitignore and installed template entries without prompts",
+			AcceptsRepositories: true,
+			RegisterFlags:       registerJsonOutputFlags,
+			Run:                 runStatusCommand,
+		},
+		{
+			Name:                RemoveCommand,
+			Description:         "Remove installed template entries, empty target directories and their .gitignore entry",
+			AcceptsRepositories: true,
+			RegisterFlags:       registerRemoveFlags,
+			Run:                 runRemoveCommand,
+		},
+		{
+			Name:          ListTemplatesCommand,
+			Description:   "List template entries of all template profiles",
+			RegisterFlags: registerJsonOutputFlags,
+			Run:           runListTemplatesCommand,
+		},
+		{
+			Name:                DoctorCommand,
+			Description:         "Check configuration, template directories and environment",
+			AcceptsRepositories: true,
+			Run:                 runDoctorCommand,
+		},
+	}
+}
+
+// Arguments without leading command are passed to "init" for backwards compatibility
+func ResolveCommand(args []string) (Command, []string) {
+	commands := GetCommands()
+	if len(args) > 0 {
+		if args[0] == HelpCommand {
+			printUsage()
+			os.Exit(0)
+		}
+		for _, command := range commands {
+			if command.Name == args[0] {
+				return command, args[1:]
+			}
+		}
+	}
+	return commands[0], args
+}
+
+func printUsage() {
+	fmt.Printf("Usage: dot-user-git-util [command] [flags] [repositories...]\n\nCommands:\n")
+	for _, command := range GetCommands() {
+		fmt.Printf("  %-16s%s\n", command.Name, command.Description)
+	}
+	fmt.Printf("\nRun \"dot-user-git-util <command> --help\" for flags of the command\n")
+}
+
+func printCommandUsage(command Command, flagSet *pflag.FlagSet) {
+	arguments := ""
+	if command.AcceptsRepositories {
+		arguments = " [repositories...]"
+	}
+	fmt.Fprintf(os.Stderr, "Usage: dot-user-git-util %s [flags]%s\n%s\n\nFlags:\n", command.Name, arguments, command.Description)
+	flagSet.PrintDefaults()
+	if command.Name == InitCommand {
+		fmt.Fprintf(os.Stderr, "\nRun \"dot-user-git-util %s\" for list of other commands\n", HelpCommand)
+	}
+}
+
+func initializeCommand(appConfig *AppConfig) (*ProcessingContext, error) {
+	gitRepositories, resolvedRepositoryArguments := resolveRepositoryRoots(appConfig.Input.GitRepositories)
+	appConfig.Input.GitRepositories = gitRepositories
+	if err := validateConfig(*appConfig); err != nil {
+		return nil, fmt.Errorf("error validating app config\n%w", err)
+	}
+	if appConfig.Config.WorktreeTarget == WorktreeTargetShared {
+		gitRepositories, err := resolveSharedWorktrees(appConfig.Input.GitRepositories)
+		if err != nil {
+			return nil, err
+		}
+		appConfig.Input.GitRepositories = gitRepositories
+	}
+	processingContext, err := InitializeProcessingContext(appConfig.Config)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing processing context\n%w", err)
+	}
+	processingContext.ResolvedRepositoryArguments = resolvedRepositoryArguments
+	return processingContext, nil
+}
+
+func initializePromptingCommand(appConfig *AppConfig) (*ProcessingContext, error) {
+	processingContext, err := initializeCommand(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	processingContext.LinePrompts, err = resolveLinePrompts(appConfig.Config)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving prompt mode\n%w", err)
+	}
+	return processingContext, nil
+}
+
+func runInitCommand(appConfig AppConfig) error {
+	processingContext, err := initializePromptingCommand(&appConfig)
+	if err != nil {
+		return err
+	}
+	if appConfig.Config.FlagPerRepoMode {
+		for _, repository := range appConfig.Input.GitRepositories {
+			result, err := RunInitializationOnRepositories(appConfig.Config, *processingContext, []string{repository})
+			if err != nil {
+				return err
+			}
+			if result != nil && result.ShouldExit {
+				return errQuit
+			}
+		}
+		return nil
+	}
+	_, err = RunInitializationOnRepositories(appConfig.Config, *processingContext, appConfig.Input.GitRepositories)
+	return err
+}
+
+// Init-only settings can still be set from environment, they're replaced so that update neither purges, removes nor ignores anything, nor installs entries that aren't installed yet
+func resetUpdateConfig(config *Config) {
+	config.SelectEntries = ""
+	config.FlagSelectAll = false
+	config.FlagSelectPreselected = false
+	config.selectInstalled = true
+	config.FlagGitignoreInclude = false
+	config.FlagGitignoreOmit = true
+	config.GitignoreRepositories = ""
+	config.FlagForceReinitialize = false
+	config.FlagSyncMode = false
+	config.FlagSkipWhereTargetExists = false
+	config.FlagSkipWhereGitignored = false
+}
+
+// Each repository is updated with entries installed in it, after single confirmation
+func runUpdateCommand(appConfig AppConfig) error {
+	resetUpdateConfig(&appConfig.Config)
+	processingContext, err := initializePromptingCommand(&appConfig)
+	if err != nil {
+		return err
+	}
+	config := appConfig.Config
+	targetDirectoryPresence, err := GetTargetDirectoryPresence(appConfig.Input.GitRepositories, config.TargetFolder)
+	if err != nil {
+		return fmt.Errorf("error resolving target directory presence\n%w", err)
+	}
+	var gitRepositories []string
+	for i, gitRepository := range appConfig.Input.GitRepositories {
+		if !(*targetDirectoryPresence)[i] {
+			fmt.Printf("%sSkipping repository %q - target directory not found%s\n", utils.ColorGreen, gitRepository, utils.Reset)
+			continue
+		}
+		gitRepositories = append(gitRepositories, gitRepository)
+	}
+	if len(gitRepositories) == 0 {
+		return nil
+	}
+	if !config.FlagYesInitialPrompt {
+		repositoryFragmentContext, err := initializeRepositorySequenceContext(config, *processingContext, gitRepositories)
+		if err != nil {
+			return fmt.Errorf("error initializing repository fragment context\n%w", err)
+		}
+		initialPromptOutput, err := runInitialPrompt(config, *processingContext, *repositoryFragmentContext)
+		if err != nil {
+			return fmt.Errorf("encountered prompt error:\n%w", err)
+		}
+		if initialPromptOutput.ShouldExit || !initialPromptOutput.Result {
+			return errQuit
+		}
+	}
+	config.FlagYesInitialPrompt = true
+	config.FlagPerRepoMode = true
+	for _, gitRepository := range gitRepositories {
+		if _, err := RunInitializationOnRepositories(config, *processingContext, []string{gitRepository}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runStatusCommand(appConfig AppConfig) error {
+	processingContext, err := initializeCommand(&appConfig)
+	if err != nil {
+		return err
+	}
+	return RunStatusOnRepositories(appConfig.Config, *processingContext, appConfig.Input.GitRepositories)
+}
+
+type TemplateEntryListing struct {
+	Identifier string `json:"identifier"`
+	Profile    string `json:"profile"`
+	Source     string `json:"source"`
+	TargetName string `json:"targetName"`
+	Rendered   bool   `json:"rendered"`
+	Visibility string `json:"visibility,omitempty"`
+}
+
+func runListTemplatesCommand(appConfig AppConfig) error {
+	processingContext, err := initializeCommand(&appConfig)
+	if err != nil {
+		return err
+	}
+	listings := make([]TemplateEntryListing, len(processingContext.TemplateDirectoryContents))
+	for i, templateDirectoryEntry := range processingContext.TemplateDirectoryContents {
+		listings[i] = TemplateEntryListing{
+			Identifier: processingContext.GetTemplateEntryIdentifier(i),
+			Profile:    processingContext.TemplateDirectoryProfiles[i],
+			Source:     processingContext.GetTemplateEntrySourceFile(i),
+			TargetName: processingContext.TemplateDirectoryTargetNames[i],
+			Rendered:   ShouldRenderTemplateEntry(appConfig.Config, templateDirectoryEntry),
+		}
+		if processingContext.HasTemplateVisibilities() {
+			listings[i].Visibility = processingContext.TemplateDirectoryVisibilities[i]
+		}
+	}
+	if appConfig.Config.FlagJsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(listings)
+	}
+	for _, templateProfile := range processingContext.TemplateProfiles {
+		fmt.Printf("%s%s%s (%s)\n", utils.FontBold, templateProfile.Name, utils.Reset, templateProfile.Directory)
+		for i, listing := range listings {
+			if listing.Profile != templateProfile.Name {
+				continue
+			}
+			line := fmt.Sprintf("  %s", listing.Identifier)
+			if listing.TargetName != processingContext.TemplateDirectoryContents[i] {
+				line += fmt.Sprintf(" -> %s%s%s", utils.ColorCyan, listing.TargetName, utils.Reset)
+			}
+			if listing.Visibility != "" {
+				line += fmt.Sprintf(" (%s)", formatTemplateVisibility(listing.Visibility))
+			}
+			fmt.Println(line)
+		}
+	}
+	return nil
+}
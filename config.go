@@ -1,8 +1,11 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/caarlos0/env"
 	"github.com/koniferous22/dot-user-git-util/utils"
@@ -22,6 +25,28 @@ type Config struct {
 	FlagSkipWhereGitignored   bool   `env:"DOT_USER_GIT_UTIL_SKIP_WHERE_GITIGNORED"`
 	FlagForceReinitialize     bool   `env:"DOT_USER_GIT_UTIL_FORCE_REINITIALIZE"`
 	FlagUnionPreselections    bool   `env:"DOT_USER_GIT_UTIL_UNION_PRESELECTIONS"`
+	FlagSyncMode              bool   `env:"DOT_USER_GIT_UTIL_SYNC_MODE"`
+	FlagDryRun                bool   `env:"DOT_USER_GIT_UTIL_DRY_RUN"`
+	SelectEntries             string `env:"DOT_USER_GIT_UTIL_SELECT"`
+	FlagSelectAll             bool   `env:"DOT_USER_GIT_UTIL_SELECT_ALL"`
+	FlagSelectPreselected     bool   `env:"DOT_USER_GIT_UTIL_SELECT_PRESELECTED"`
+	NoTtyMode                 string `env:"DOT_USER_GIT_UTIL_NO_TTY" envDefault:"fail"`
+	FlagRecursiveTemplate     bool   `env:"DOT_USER_GIT_UTIL_RECURSIVE_TEMPLATE"`
+	FlagIncludeNonExecutable  bool   `env:"DOT_USER_GIT_UTIL_INCLUDE_NON_EXECUTABLE"`
+	FlagRenderTemplates       bool   `env:"DOT_USER_GIT_UTIL_RENDER_TEMPLATES"`
+	ProfileCollision          string `env:"DOT_USER_GIT_UTIL_PROFILE_COLLISION" envDefault:"error"`
+	ConflictPolicy            string `env:"DOT_USER_GIT_UTIL_CONFLICT" envDefault:"prompt"`
+	FlagJsonOutput            bool   `env:"DOT_USER_GIT_UTIL_JSON_OUTPUT"`
+	FlagRemoveManifestOnly    bool   `env:"DOT_USER_GIT_UTIL_REMOVE_MANIFEST_ONLY"`
+	ScanRoot                  string `env:"DOT_USER_GIT_UTIL_SCAN_ROOT"`
+	ScanMaxDepth              int    `env:"DOT_USER_GIT_UTIL_SCAN_MAX_DEPTH" envDefault:"-1"`
+	ScanExclude               string `env:"DOT_USER_GIT_UTIL_SCAN_EXCLUDE"`
+	FlagScanFollowSymlinks    bool   `env:"DOT_USER_GIT_UTIL_SCAN_FOLLOW_SYMLINKS"`
+	WorktreeTarget            string `env:"DOT_USER_GIT_UTIL_WORKTREE_TARGET" envDefault:"own"`
+	IgnoreTarget              string `env:"DOT_USER_GIT_UTIL_IGNORE_TARGET"`
+	GitignoreRepositories     string `env:"DOT_USER_GIT_UTIL_GITIGNORE_REPOSITORIES"`
+	// Set by "update", selects installed entries only, without template rule detections
+	selectInstalled bool
 }
 
 type Input struct {
@@ -33,48 +58,180 @@ type AppConfig struct {
 	Input  Input
 }
 
+func (config Config) HasSelectionFlag() bool {
+	return config.SelectEntries != "" || config.FlagSelectAll || config.FlagSelectPreselected || config.selectInstalled
+}
+
+// Unless configured, ".gitignore" is used when ignore prompt is skipped
+func (config Config) GetIgnoreTarget() string {
+	if config.IgnoreTarget == "" {
+		return IgnoreTargetGitignore
+	}
+	return config.IgnoreTarget
+}
+
 func validateConfig(appConfig AppConfig) error {
-	if _, err := utils.ValidateDirectoryExists(appConfig.Config.TemplateDirectory); err != nil {
-		return fmt.Errorf("template directory %q doesn't exists", appConfig.Config.TemplateDirectory)
+	selectionFlagCount := 0
+	for _, isSet := range []bool{appConfig.Config.SelectEntries != "", appConfig.Config.FlagSelectAll, appConfig.Config.FlagSelectPreselected} {
+		if isSet {
+			selectionFlagCount++
+		}
 	}
-	for _, gitRepository := range appConfig.Input.GitRepositories {
-		gitRepositoryAbsPath, err := filepath.Abs(gitRepository)
-		if err != nil {
-			return fmt.Errorf("error resolving absolute path of input argument %q", gitRepository)
+	if selectionFlagCount > 1 {
+		return fmt.Errorf("flags \"select\", \"select-all\" and \"select-preselected\" are mutually exclusive")
+	}
+	if appConfig.Config.NoTtyMode != NoTtyModeFail && appConfig.Config.NoTtyMode != NoTtyModeLine {
+		return fmt.Errorf("invalid \"no-tty\" mode %q, expected %q or %q", appConfig.Config.NoTtyMode, NoTtyModeFail, NoTtyModeLine)
+	}
+	if appConfig.Config.ConflictPolicy != ConflictPolicyPrompt && !slices.Contains(ConflictResolutions, appConfig.Config.ConflictPolicy) {
+		return fmt.Errorf("invalid \"conflict\" policy %q, expected %q or one of %q", appConfig.Config.ConflictPolicy, ConflictPolicyPrompt, ConflictResolutions)
+	}
+	if appConfig.Config.FlagGitignoreInclude && appConfig.Config.FlagGitignoreOmit {
+		return fmt.Errorf("flags \"gitignore-yes\" and \"gitignore-no\" are mutually exclusive")
+	}
+	if appConfig.Config.GitignoreRepositories != "" && (appConfig.Config.FlagGitignoreInclude || appConfig.Config.FlagGitignoreOmit) {
+		return fmt.Errorf("flag \"gitignore-repos\" can't be combined with \"gitignore-yes\" or \"gitignore-no\"")
+	}
+	if _, err := parseGitignoreRepositories(appConfig.Config.GitignoreRepositories); err != nil {
+		return err
+	}
+	if appConfig.Config.IgnoreTarget != "" && !slices.Contains(IgnoreTargets, appConfig.Config.IgnoreTarget) {
+		return fmt.Errorf("invalid \"ignore-target\" %q, expected one of %q", appConfig.Config.IgnoreTarget, IgnoreTargets)
+	}
+	templateProfiles, err := ParseTemplateProfiles(appConfig.Config.TemplateDirectory)
+	if err != nil {
+		return err
+	}
+	for _, templateProfile := range templateProfiles {
+		if _, err := utils.ValidateDirectoryExists(templateProfile.Directory); err != nil {
+			return fmt.Errorf("template directory %q doesn't exists", templateProfile.Directory)
 		}
-		gitRepositoryDotGitDirectory := filepath.Join(gitRepositoryAbsPath, DotGitDirectory)
-		if result, validationErr := utils.ValidateDirectoryExists(gitRepositoryDotGitDirectory); !result {
-			err := fmt.Errorf("%q is not a git repository", gitRepositoryAbsPath)
-			if validationErr != nil {
-				err = fmt.Errorf("unable to stat %q\n%w", gitRepositoryDotGitDirectory, validationErr)
-			}
+	}
+	if appConfig.Config.WorktreeTarget != WorktreeTargetOwn && appConfig.Config.WorktreeTarget != WorktreeTargetShared {
+		return fmt.Errorf("invalid \"worktree-target\" %q, expected %q or %q", appConfig.Config.WorktreeTarget, WorktreeTargetOwn, WorktreeTargetShared)
+	}
+	switch appConfig.Config.ProfileCollision {
+	case ProfileCollisionError, ProfileCollisionFirst, ProfileCollisionLast:
+	default:
+		return fmt.Errorf("invalid \"profile-collision\" %q, expected %q, %q or %q", appConfig.Config.ProfileCollision, ProfileCollisionError, ProfileCollisionFirst, ProfileCollisionLast)
+	}
+	for _, gitRepository := range appConfig.Input.GitRepositories {
+		if err := validateGitRepository(gitRepository); err != nil {
 			return err
 		}
-		if err != nil {
-			return fmt.Errorf("error validating %q", gitRepository)
-		}
 	}
 	return nil
 }
 
-func InitializeConfig() (*AppConfig, error) {
+func validateGitRepository(gitRepository string) error {
+	gitRepositoryAbsPath, err := filepath.Abs(gitRepository)
+	if err != nil {
+		return fmt.Errorf("error resolving absolute path of input argument %q", gitRepository)
+	}
+	if _, err := os.Stat(filepath.Join(gitRepositoryAbsPath, DotGitDirectory)); os.IsNotExist(err) {
+		return fmt.Errorf("%q is not a git repository", gitRepositoryAbsPath)
+	}
+	if _, err := resolveGitDirectory(gitRepositoryAbsPath); err != nil {
+		return fmt.Errorf("unable to resolve git directory of %q\n%w", gitRepositoryAbsPath, err)
+	}
+	return nil
+}
+
+// Shared by all commands
+func registerTemplateFlags(flagSet *pflag.FlagSet, config *Config) {
+	flagSet.StringVarP(&config.TargetFolder, "target-folder", "t", config.TargetFolder, "Target folder in .git repositories")
+	flagSet.Var(&templateDirectoriesValue{templateDirectories: &config.TemplateDirectory}, "template-dir", "Template directory, optionally named as \"<profile>=<directory>\", repeat for multiple profiles")
+	flagSet.StringVar(&config.ProfileCollision, "profile-collision", config.ProfileCollision, "Resolution of entries provided by multiple template profiles - \"error\", \"first\" or \"last\"")
+	flagSet.BoolVarP(&config.FlagRecursiveTemplate, "recursive", "r", config.FlagRecursiveTemplate, "Include executables from nested template directories, mirrored under target directory")
+	flagSet.BoolVarP(&config.FlagIncludeNonExecutable, "include-non-executable", "a", config.FlagIncludeNonExecutable, "Include non-executable template files (configs, env files, READMEs), detected by presence instead of exec permissions")
+	flagSet.BoolVar(&config.FlagRenderTemplates, "render-templates", config.FlagRenderTemplates, "Render \".tmpl\" files with Go text/template per repository, suffix is stripped in target directory")
+}
+
+// Shared by commands accepting repositories
+func registerScanFlags(flagSet *pflag.FlagSet, config *Config) {
+	flagSet.StringVar(&config.ScanRoot, "scan", config.ScanRoot, "Find git repositories under directory, in addition to repositories passed as arguments")
+	flagSet.IntVar(&config.ScanMaxDepth, "scan-max-depth", config.ScanMaxDepth, "Maximum directory depth of scanned repositories relative to scan root, negative for unlimited")
+	flagSet.StringVar(&config.ScanExclude, "scan-exclude", config.ScanExclude, "Comma-separated globs of directories skipped during scan, matched against directory name and path relative to scan root")
+	flagSet.BoolVar(&config.FlagScanFollowSymlinks, "scan-follow-symlinks", config.FlagScanFollowSymlinks, "Follow symlinked directories during scan")
+	flagSet.StringVar(&config.WorktreeTarget, "worktree-target", config.WorktreeTarget, "Target directory of linked git worktrees - \"own\", or \"shared\" with main worktree")
+}
+
+func registerPromptFlags(flagSet *pflag.FlagSet, config *Config) {
+	flagSet.BoolVarP(&config.FlagYesInitialPrompt, "yes", "y", config.FlagYesInitialPrompt, "Yes for initial prompt")
+	flagSet.StringVar(&config.NoTtyMode, "no-tty", config.NoTtyMode, "Behaviour when stdin is not a terminal - \"fail\" unless prompts are skipped by flags, or \"line\" for plain line-based prompts")
+}
+
+func registerUpdateFlags(flagSet *pflag.FlagSet, config *Config) {
+	registerPromptFlags(flagSet, config)
+	flagSet.BoolVarP(&config.FlagDryRun, "dry-run", "n", config.FlagDryRun, "Print planned changes instead of modifying repositories")
+	flagSet.StringVar(&config.ConflictPolicy, "conflict", config.ConflictPolicy, "Resolution of files modified since installed - \"prompt\", \"skip\", \"overwrite\", \"keep-both\" (local copy is moved to <file>.orig) or \"merge\"")
+}
+
+func registerInitializationFlags(flagSet *pflag.FlagSet, config *Config) {
+	registerUpdateFlags(flagSet, config)
+	flagSet.BoolVarP(&config.FlagPerRepoMode, "per-repo-mode", "p", config.FlagPerRepoMode, "Run prompts for each repository")
+	flagSet.BoolVarP(&config.FlagForceReinitialize, "force-reinit", "f", config.FlagForceReinitialize, "Force removal of all previous contents on visit + disables preselection")
+	flagSet.BoolVarP(&config.FlagSkipWhereTargetExists, "skip-where-target-exists", "e", config.FlagSkipWhereTargetExists, "Skip for arguments where target already exists - otherwise trigger update")
+	flagSet.BoolVarP(&config.FlagSkipWhereGitignored, "skip-where-gitignored", "g", config.FlagSkipWhereGitignored, "Skip for arguments where target directory is .gitignored - otherwise trigger update")
+	flagSet.BoolVarP(&config.FlagUnionPreselections, "union-preselections", "u", config.FlagUnionPreselections, "Pre-select if script occurs in at least one arg (repository), doesn't work with \"per-repo-mode\"")
+	flagSet.BoolVarP(&config.FlagSyncMode, "sync", "s", config.FlagSyncMode, "Remove deselected scripts from target directories, implies \"union-preselections\"")
+	flagSet.StringVar(&config.SelectEntries, "select", config.SelectEntries, "Comma-separated template entries to select, skips selection prompt")
+	flagSet.BoolVar(&config.FlagSelectAll, "select-all", config.FlagSelectAll, "Select all template entries, skips selection prompt")
+	flagSet.BoolVar(&config.FlagSelectPreselected, "select-preselected", config.FlagSelectPreselected, "Select preselected template entries, skips selection prompt")
+	flagSet.BoolVar(&config.FlagGitignoreInclude, "gitignore-yes", config.FlagGitignoreInclude, "Yes for gitignore y/n prompt")
+	flagSet.BoolVar(&config.FlagGitignoreOmit, "gitignore-no", config.FlagGitignoreOmit, "No for gitignore y/n prompt")
+	flagSet.StringVar(&config.GitignoreRepositories, "gitignore-repos", config.GitignoreRepositories, "Comma-separated globs of repositories where target directory should be ignored, matched against repository path or name - answers per-repository gitignore prompt, unmatched repositories are left as they are")
+	flagSet.StringVar(&config.IgnoreTarget, "ignore-target", config.IgnoreTarget, "Where target directory is ignored - \"gitignore\", \"exclude\" for .git/info/exclude or \"global\" for core.excludesFile, prompted unless set")
+}
+
+func registerRemoveFlags(flagSet *pflag.FlagSet, config *Config) {
+	registerPromptFlags(flagSet, config)
+	flagSet.BoolVarP(&config.FlagDryRun, "dry-run", "n", config.FlagDryRun, "Print planned changes instead of modifying repositories")
+	flagSet.BoolVar(&config.FlagRemoveManifestOnly, "manifest-only", config.FlagRemoveManifestOnly, "Remove only files recorded as installed in install manifest, instead of all files matching template entries")
+}
+
+func registerJsonOutputFlags(flagSet *pflag.FlagSet, config *Config) {
+	flagSet.BoolVar(&config.FlagJsonOutput, "json", config.FlagJsonOutput, "Print output as JSON")
+}
+
+func InitializeConfig(command Command, args []string) (*AppConfig, error) {
 	config := Config{}
 	if err := env.Parse(&config); err != nil {
 		return nil, fmt.Errorf("error parsing config from env variables\n%+v", err)
 	}
 	defaultCliArgs := []string{"."}
-	pflag.StringVarP(&config.TargetFolder, "target-folder", "t", config.TargetFolder, "Target folder in .git repositories")
-	pflag.BoolVarP(&config.FlagPerRepoMode, "per-repo-mode", "p", config.FlagPerRepoMode, "Run prompts for each repository")
-	pflag.BoolVarP(&config.FlagYesInitialPrompt, "yes", "y", config.FlagYesInitialPrompt, "Yes for initial prompt")
-	pflag.BoolVarP(&config.FlagForceReinitialize, "force-reinit", "f", config.FlagForceReinitialize, "Force removal of all previous contents on visit + disables preselection")
-	pflag.BoolVarP(&config.FlagSkipWhereTargetExists, "skip-where-target-exists", "e", config.FlagSkipWhereTargetExists, "Skip for arguments where target already exists - otherwise trigger update")
-	pflag.BoolVarP(&config.FlagSkipWhereGitignored, "skip-where-gitignored", "g", config.FlagSkipWhereGitignored, "Skip for arguments where target directory is .gitignored - otherwise trigger update")
-	pflag.BoolVarP(&config.FlagUnionPreselections, "union-preselections", "u", config.FlagUnionPreselections, "Pre-select if script occurs in at least one arg (repository), doesn't work with \"per-repo-mode\"")
-	pflag.StringVar(&config.TemplateDirectory, "template-dir", config.TemplateDirectory, "Template directory")
-	pflag.BoolVar(&config.FlagGitignoreInclude, "gitignore-yes", config.FlagGitignoreInclude, "Yes for gitignore y/n prompt")
-	pflag.BoolVar(&config.FlagGitignoreOmit, "gitignore-no", config.FlagGitignoreOmit, "No for gitignore y/n prompt")
-	pflag.Parse()
-	gitRepositories := pflag.Args()
+	flagSet := pflag.NewFlagSet(command.Name, pflag.ContinueOnError)
+	flagSet.Usage = func() {
+		printCommandUsage(command, flagSet)
+	}
+	registerTemplateFlags(flagSet, &config)
+	if command.AcceptsRepositories {
+		registerScanFlags(flagSet, &config)
+	}
+	if command.RegisterFlags != nil {
+		command.RegisterFlags(flagSet, &config)
+	}
+	// Parsing errors are printed with usage by flag set
+	if err := flagSet.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			os.Exit(0)
+		}
+		os.Exit(2)
+	}
+	gitRepositories := flagSet.Args()
+	if !command.AcceptsRepositories {
+		if len(gitRepositories) > 0 {
+			return nil, fmt.Errorf("command %q doesn't accept arguments, got %q", command.Name, gitRepositories)
+		}
+		return &AppConfig{Config: config}, nil
+	}
+	if config.ScanRoot != "" {
+		scannedRepositories, err := ScanGitRepositories(config)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning for git repositories\n%w", err)
+		}
+		gitRepositories = append(gitRepositories, scannedRepositories...)
+	}
 	if len(gitRepositories) == 0 {
 		gitRepositories = defaultCliArgs
 	}
@@ -84,7 +241,9 @@ func InitializeConfig() (*AppConfig, error) {
 		if err != nil {
 			return nil, fmt.Errorf("error resolving absolute path of input argument %q", gitRepository)
 		}
-		gitRepositoryAbsolutePaths = append(gitRepositoryAbsolutePaths, gitRepositoryAbsPath)
+		if !slices.Contains(gitRepositoryAbsolutePaths, gitRepositoryAbsPath) {
+			gitRepositoryAbsolutePaths = append(gitRepositoryAbsolutePaths, gitRepositoryAbsPath)
+		}
 	}
 	app := &AppConfig{Config: config, Input: Input{GitRepositories: gitRepositoryAbsolutePaths}}
 	return app, nil
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/koniferous22/dot-user-git-util/prompts"
+	"github.com/koniferous22/dot-user-git-util/utils"
+)
+
+const (
+	ConflictPolicyPrompt    = "prompt"
+	ConflictPolicySkip      = "skip"
+	ConflictPolicyOverwrite = "overwrite"
+	ConflictPolicyKeepBoth  = "keep-both"
+	ConflictPolicyMerge     = "merge"
+)
+
+var ConflictResolutions = []string{ConflictPolicySkip, ConflictPolicyOverwrite, ConflictPolicyKeepBoth, ConflictPolicyMerge}
+
+// Deselected entries in sync mode have no template contents to merge with
+var ConflictRemovalResolutions = []string{ConflictPolicySkip, ConflictPolicyOverwrite, ConflictPolicyKeepBoth}
+
+// With "keep-both", local copy is moved aside with the suffix, before template contents are installed or removed
+const ConflictOriginalSuffix = ".orig"
+
+var errConflictResolutionAborted = errors.New("aborted during conflict resolution")
+
+var errMergeConflicts = errors.New("merge left conflict markers")
+
+func describeConflict(destinationFile string, status TemplateEntryStatus) string {
+	if status == TemplateEntryStatusDiffers {
+		return fmt.Sprintf("%q differs from the template and has no install record", destinationFile)
+	}
+	return fmt.Sprintf("%q was modified since it was installed", destinationFile)
+}
+
+func runConflictPrompt(processingContext ProcessingContext, conflictDescription string, resolutions []string) (*prompts.SelectModel, error) {
+	promptMessage := fmt.Sprintf("%s%s%s, pick resolution", utils.FontBold, conflictDescription, utils.Reset)
+	conflictPromptModel := prompts.CreateSelectModel(promptMessage, resolutions)
+	result, err := runPrompt(processingContext, conflictPromptModel)
+	if err != nil {
+		return nil, fmt.Errorf("error during conflict prompt:\n%w", err)
+	}
+	if result, ok := result.(prompts.SelectModel); ok {
+		return &result, nil
+	}
+	return nil, fmt.Errorf("error retrieving prompt results:\n%w", err)
+}
+
+func resolveConflictResolution(config Config, processingContext ProcessingContext, conflictDescription string, resolutions []string) (string, error) {
+	if config.ConflictPolicy != ConflictPolicyPrompt {
+		return config.ConflictPolicy, nil
+	}
+	if !processingContext.LinePrompts && !isTerminalAvailable() {
+		return "", fmt.Errorf(
+			"%s, but stdin is not a terminal\nuse \"--conflict=<policy>\" with one of %q",
+			conflictDescription,
+			ConflictResolutions,
+		)
+	}
+	conflictPromptOutput, err := runConflictPrompt(processingContext, conflictDescription, resolutions)
+	if err != nil {
+		return "", err
+	}
+	if conflictPromptOutput.ShouldExit {
+		return "", errConflictResolutionAborted
+	}
+	return resolutions[conflictPromptOutput.Cursor], nil
+}
+
+// Moves local copy to "<file>.orig", existing backup isn't overwritten, as it might hold earlier local changes
+func backupLocalCopy(destinationFile string) error {
+	backupFile := destinationFile + ConflictOriginalSuffix
+	if _, err := os.Lstat(backupFile); err == nil {
+		return fmt.Errorf("backup %q already exists, remove it first", backupFile)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	return os.Rename(destinationFile, backupFile)
+}
+
+// Returns whether template contents were installed to destination, merge leaving conflict markers results in "errMergeConflicts"
+func applyConflictResolution(resolution string, destinationFile string, templateContents []byte, baseContents []byte, mode os.FileMode) (bool, error) {
+	switch resolution {
+	case ConflictPolicyOverwrite:
+		return true, utils.WriteFile(destinationFile, templateContents, mode)
+	case ConflictPolicyKeepBoth:
+		if err := backupLocalCopy(destinationFile); err != nil {
+			return false, err
+		}
+		return true, utils.WriteFile(destinationFile, templateContents, mode)
+	case ConflictPolicyMerge:
+		// Without snapshot of installed contents, whole files would conflict
+		if baseContents == nil {
+			fmt.Fprintf(os.Stderr, "%sNo install snapshot of %q to merge with, keeping local copy as %q%s\n", utils.ColorYellow, destinationFile, destinationFile+ConflictOriginalSuffix, utils.Reset)
+			return applyConflictResolution(ConflictPolicyKeepBoth, destinationFile, templateContents, baseContents, mode)
+		}
+		localContents, err := os.ReadFile(destinationFile)
+		if err != nil {
+			return false, err
+		}
+		merged, hasConflicts := utils.MergeThreeWay(string(baseContents), string(localContents), string(templateContents))
+		if err := utils.WriteFile(destinationFile, []byte(merged), mode); err != nil {
+			return false, err
+		}
+		if hasConflicts {
+			return true, fmt.Errorf("%w in %q", errMergeConflicts, destinationFile)
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// Returns whether deselected entry can be removed, local copy is kept unless resolved by "overwrite", or moved aside by "keep-both"
+func applyConflictRemoval(resolution string, destinationFile string) (bool, error) {
+	switch resolution {
+	case ConflictPolicyOverwrite:
+		return true, nil
+	case ConflictPolicyKeepBoth:
+		return true, backupLocalCopy(destinationFile)
+	}
+	fmt.Fprintf(os.Stderr, "%sKeeping deselected %q, it differs from the template%s\n", utils.ColorYellow, destinationFile, utils.Reset)
+	return false, nil
+}
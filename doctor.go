@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/koniferous22/dot-user-git-util/utils"
+)
+
+const (
+	DoctorCheckOk   = "OK"
+	DoctorCheckWarn = "WARN"
+	DoctorCheckFail = "FAIL"
+)
+
+var errDoctorChecksFailed = errors.New("doctor checks failed")
+
+type doctorCheck struct {
+	Result      string
+	Description string
+	Err         error
+}
+
+func (check doctorCheck) Print() {
+	color := utils.ColorGreen
+	switch check.Result {
+	case DoctorCheckWarn:
+		color = utils.ColorYellow
+	case DoctorCheckFail:
+		color = utils.ColorRed
+	}
+	fmt.Printf("%s%-4s%s %s\n", color, check.Result, utils.Reset, check.Description)
+	if check.Err != nil {
+		for _, line := range strings.Split(check.Err.Error(), "\n") {
+			fmt.Printf("     %s\n", line)
+		}
+	}
+}
+
+func checkDoctorError(description string, err error, failureResult string) doctorCheck {
+	if err != nil {
+		return doctorCheck{Result: failureResult, Description: description, Err: err}
+	}
+	return doctorCheck{Result: DoctorCheckOk, Description: description}
+}
+
+func checkTargetFolder(targetFolder string) error {
+	cleanTargetFolder := filepath.Clean(targetFolder)
+	switch {
+	case filepath.IsAbs(targetFolder):
+		return fmt.Errorf("target folder has to be relative to repository root")
+	case cleanTargetFolder == "." || cleanTargetFolder == ".." || strings.HasPrefix(cleanTargetFolder, ".."+string(filepath.Separator)):
+		return fmt.Errorf("target folder has to be nested in repository root")
+	case strings.Split(cleanTargetFolder, string(filepath.Separator))[0] == DotGitDirectory:
+		return fmt.Errorf("target folder can't be placed in %q directory", DotGitDirectory)
+	}
+	return nil
+}
+
+func resolveDoctorChecks(appConfig AppConfig) []doctorCheck {
+	config := appConfig.Config
+	var checks []doctorCheck
+	checks = append(checks, checkDoctorError("configuration options", validateConfig(AppConfig{Config: config}), DoctorCheckFail))
+	templateProfiles, err := ParseTemplateProfiles(config.TemplateDirectory)
+	checks = append(checks, checkDoctorError(fmt.Sprintf("template profiles %q", config.TemplateDirectory), err, DoctorCheckFail))
+	for _, templateProfile := range templateProfiles {
+		var err error
+		if ok, validationErr := utils.ValidateDirectoryExists(templateProfile.Directory); !ok {
+			err = fmt.Errorf("template directory doesn't exist")
+			if validationErr != nil {
+				err = validationErr
+			}
+		}
+		checks = append(checks, checkDoctorError(fmt.Sprintf("template profile %q (%s)", templateProfile.Name, templateProfile.Directory), err, DoctorCheckFail))
+	}
+	if processingContext, err := InitializeProcessingContext(config); err != nil {
+		checks = append(checks, checkDoctorError("template entries and rules", err, DoctorCheckFail))
+	} else {
+		checks = append(checks, checkDoctorError(fmt.Sprintf("%d template entries, %d template rules", len(processingContext.TemplateDirectoryContents), len(processingContext.TemplateRules)), nil, DoctorCheckFail))
+	}
+	checks = append(checks, checkDoctorError(fmt.Sprintf("target folder %q", config.TargetFolder), checkTargetFolder(config.TargetFolder), DoctorCheckFail))
+	gitBinaryResult := DoctorCheckWarn
+	if config.FlagRenderTemplates {
+		gitBinaryResult = DoctorCheckFail
+	}
+	_, err = exec.LookPath("git")
+	checks = append(checks, checkDoctorError("git executable, used for rendering templates", err, gitBinaryResult))
+	var terminalErr error
+	if !isTerminalAvailable() {
+		terminalErr = fmt.Errorf("prompts require \"--no-tty=%s\" or flags answering them", NoTtyModeLine)
+	}
+	checks = append(checks, checkDoctorError("stdin is a terminal", terminalErr, DoctorCheckWarn))
+	gitRepositories, resolvedRepositoryArguments := resolveRepositoryRoots(appConfig.Input.GitRepositories)
+	for _, gitRepository := range gitRepositories {
+		for _, resolvedArgument := range resolvedRepositoryArguments[gitRepository] {
+			checks = append(checks, checkDoctorError(fmt.Sprintf("%q resolved to repository %q", resolvedArgument, gitRepository), nil, DoctorCheckFail))
+		}
+		if err := validateGitRepository(gitRepository); err != nil {
+			checks = append(checks, checkDoctorError(fmt.Sprintf("repository %q", gitRepository), err, DoctorCheckFail))
+			continue
+		}
+		_, err := LoadInstallManifest(gitRepository, config.TargetFolder)
+		checks = append(checks, checkDoctorError(fmt.Sprintf("repository %q, install manifest", gitRepository), err, DoctorCheckFail))
+	}
+	return checks
+}
+
+func runDoctorCommand(appConfig AppConfig) error {
+	failed := false
+	for _, check := range resolveDoctorChecks(appConfig) {
+		check.Print()
+		if check.Result == DoctorCheckFail {
+			failed = true
+		}
+	}
+	if failed {
+		return errDoctorChecksFailed
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/koniferous22/dot-user-git-util/utils"
+)
+
+func formatPlanOperation(color string, operation string, path string) string {
+	return fmt.Sprintf("  [%s%s%s] %s\n", color, operation, utils.Reset, path)
+}
+
+// Mirrors "processInitialization" and "processGitignore" without touching the repositories
+func printInitializationPlan(config Config, processingContext ProcessingContext, repositoryFragmentContext RepositoryFragmentContext, templateSelections []bool, gitignoreSelections []bool, ignorePatterns []string, ignoreTarget string) error {
+	gitignorePattern := GetGitignorePattern(config.TargetFolder)
+	formattedIgnorePatterns := make([]string, len(ignorePatterns))
+	for i, ignorePattern := range ignorePatterns {
+		formattedIgnorePatterns[i] = fmt.Sprintf("%q", ignorePattern)
+	}
+	for i, gitRepository := range repositoryFragmentContext.InputGitRepositories {
+		targetPath := filepath.Join(gitRepository, config.TargetFolder)
+		plan := fmt.Sprintf("%sPlan for %q%s\n", utils.FontBold, gitRepository, utils.Reset)
+
+		if !repositoryFragmentContext.TargetDirectoryPresence[i] {
+			plan += formatPlanOperation(utils.ColorBlue, "MKDIR", targetPath)
+		} else if config.FlagForceReinitialize {
+			contents, err := utils.ListDirectoryContents(targetPath)
+			if err != nil {
+				return err
+			}
+			for _, targetDirectoryEntry := range contents {
+				plan += formatPlanOperation(utils.ColorRed, "DELETE", targetPath+string(filepath.Separator)+targetDirectoryEntry)
+			}
+		}
+
+		templateData := resolveRepositoryTemplateData(config, processingContext, gitRepository)
+		for j, isSelected := range templateSelections {
+			templateFile := processingContext.TemplateDirectoryContents[j]
+			destinationFile := filepath.Join(targetPath, processingContext.TemplateDirectoryTargetNames[j])
+			if !isSelected {
+				if !config.FlagSyncMode || !repositoryFragmentContext.TemplateDirectoryOccurrences[j][i] {
+					continue
+				}
+				if !config.FlagForceReinitialize && repositoryFragmentContext.TemplateDirectoryStatuses[j][i].IsConflicting() {
+					plan += formatPlanOperation(utils.ColorRed, "CONFLICT", fmt.Sprintf("%s deselected (%s)", destinationFile, config.ConflictPolicy))
+				} else {
+					plan += formatPlanOperation(utils.ColorRed, "DELETE", destinationFile)
+				}
+				continue
+			}
+			sourceFile := processingContext.GetTemplateEntrySourceFile(j)
+			sourceInfo, err := os.Stat(sourceFile)
+			if err != nil {
+				return err
+			}
+			if ShouldRenderTemplateEntry(config, templateFile) {
+				if _, err := renderTemplateFile(sourceFile, templateData); err != nil {
+					return err
+				}
+				plan += formatPlanOperation(utils.ColorPurple, "RENDER", fmt.Sprintf("%s -> %s", sourceFile, destinationFile))
+			}
+			sourceMode := sourceInfo.Mode().Perm()
+			destinationInfo, err := os.Stat(destinationFile)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			if destinationInfo == nil || config.FlagForceReinitialize {
+				plan += formatPlanOperation(utils.ColorBlue, "CREATE", destinationFile)
+				plan += formatPlanOperation(utils.ColorCyan, "CHMOD", fmt.Sprintf("%s %#o", destinationFile, sourceMode))
+				continue
+			}
+			if repositoryFragmentContext.TemplateDirectoryStatuses[j][i].IsConflicting() {
+				plan += formatPlanOperation(utils.ColorRed, "CONFLICT", fmt.Sprintf("%s (%s)", destinationFile, config.ConflictPolicy))
+			} else {
+				plan += formatPlanOperation(utils.ColorYellow, "OVERWRITE", destinationFile)
+			}
+			if destinationMode := destinationInfo.Mode().Perm(); destinationMode != sourceMode {
+				plan += formatPlanOperation(utils.ColorCyan, "CHMOD", fmt.Sprintf("%s %#o -> %#o", destinationFile, destinationMode, sourceMode))
+			}
+		}
+
+		if gitignoreSelections[i] && !repositoryFragmentContext.GitignorePresence[i] {
+			ignoreFilePath, err := GetIgnoreFilePath(gitRepository, ignoreTarget)
+			if err != nil {
+				return err
+			}
+			plan += formatPlanOperation(utils.ColorPurple, "IGNORE", fmt.Sprintf("%s in managed block of %s", strings.Join(formattedIgnorePatterns, ", "), ignoreFilePath))
+		} else if gitignoreSelections[i] && processingContext.HasTemplateVisibilities() {
+			managedIgnoreFilePaths, err := getManagedIgnoreFilePaths(gitRepository, config.TargetFolder)
+			if err != nil {
+				return err
+			}
+			for _, managedIgnoreFilePath := range managedIgnoreFilePaths {
+				plan += formatPlanOperation(utils.ColorPurple, "IGNORE", fmt.Sprintf("%s in managed block of %s", strings.Join(formattedIgnorePatterns, ", "), managedIgnoreFilePath))
+			}
+		} else if !gitignoreSelections[i] && repositoryFragmentContext.GitignorePresence[i] {
+			ignoreTargets, err := getManagedBlockIgnoreTargets(gitRepository, config.TargetFolder)
+			if err != nil {
+				return err
+			}
+			for _, managedIgnoreTarget := range ignoreTargets {
+				if managedIgnoreTarget == IgnoreTargetGlobal {
+					continue
+				}
+				ignoreFilePath, err := GetIgnoreFilePath(gitRepository, managedIgnoreTarget)
+				if err != nil {
+					return err
+				}
+				plan += formatPlanOperation(utils.ColorPurple, "UNIGNORE", fmt.Sprintf("%q from %s", gitignorePattern, ignoreFilePath))
+			}
+		}
+		fmt.Print(plan)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/koniferous22/dot-user-git-util/utils"
+)
+
+type TemplateEntryStatus int
+
+const (
+	TemplateEntryStatusNew TemplateEntryStatus = iota
+	TemplateEntryStatusIdentical
+	TemplateEntryStatusModified
+	TemplateEntryStatusOutdated
+	// Without install record, it's unknown whether repository copy was modified locally or template changed
+	TemplateEntryStatusDiffers
+)
+
+func (status TemplateEntryStatus) String() string {
+	switch status {
+	case TemplateEntryStatusIdentical:
+		return "identical"
+	case TemplateEntryStatusModified:
+		return "modified locally"
+	case TemplateEntryStatusOutdated:
+		return "outdated"
+	case TemplateEntryStatusDiffers:
+		return "differs"
+	}
+	return "new"
+}
+
+func (status TemplateEntryStatus) Color() string {
+	switch status {
+	case TemplateEntryStatusIdentical:
+		return utils.ColorGreen
+	case TemplateEntryStatusModified:
+		return utils.ColorPurple
+	case TemplateEntryStatusOutdated:
+		return utils.ColorYellow
+	case TemplateEntryStatusDiffers:
+		return utils.ColorCyan
+	}
+	return utils.ColorBlue
+}
+
+// Installing over repository copy could discard local changes
+func (status TemplateEntryStatus) IsConflicting() bool {
+	return status == TemplateEntryStatusModified || status == TemplateEntryStatusDiffers
+}
+
+// Contents as written to target directory, i.e. rendered for templates
+func resolveTemplateEntryContents(config Config, processingContext ProcessingContext, i int, templateData RepositoryTemplateData) ([]byte, error) {
+	sourceFile := processingContext.GetTemplateEntrySourceFile(i)
+	if ShouldRenderTemplateEntry(config, processingContext.TemplateDirectoryContents[i]) {
+		return renderTemplateFile(sourceFile, templateData)
+	}
+	contents, err := os.ReadFile(sourceFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading template file %q:\n%w", sourceFile, err)
+	}
+	return contents, nil
+}
+
+// Differing contents are compared with hash recorded on install
+// Without install record, modification times aren't reliable (e.g. reset by checkout), so contents only differ
+func resolveTemplateEntryStatus(config Config, processingContext ProcessingContext, i int, gitRepository string, templateData RepositoryTemplateData, installManifest InstallManifest) (TemplateEntryStatus, error) {
+	destinationFile := filepath.Join(gitRepository, config.TargetFolder, processingContext.TemplateDirectoryTargetNames[i])
+	destinationContents, err := os.ReadFile(destinationFile)
+	if os.IsNotExist(err) {
+		return TemplateEntryStatusNew, nil
+	}
+	if err != nil {
+		return TemplateEntryStatusNew, err
+	}
+	templateContents, err := resolveTemplateEntryContents(config, processingContext, i, templateData)
+	if err != nil {
+		return TemplateEntryStatusNew, err
+	}
+	destinationHash := utils.HashContents(destinationContents)
+	if destinationHash == utils.HashContents(templateContents) {
+		return TemplateEntryStatusIdentical, nil
+	}
+	if installedFile, ok := installManifest.Files[processingContext.TemplateDirectoryTargetNames[i]]; ok {
+		if destinationHash == installedFile.Hash {
+			return TemplateEntryStatusOutdated, nil
+		}
+		return TemplateEntryStatusModified, nil
+	}
+	return TemplateEntryStatusDiffers, nil
+}
+
+// Indexed by template directory entry, then by repository
+func resolveTemplateStatuses(config Config, processingContext ProcessingContext, gitRepositories []string, installManifests []InstallManifest) (*[][]TemplateEntryStatus, error) {
+	result := make([][]TemplateEntryStatus, len(processingContext.TemplateDirectoryContents))
+	for i := range result {
+		result[i] = make([]TemplateEntryStatus, len(gitRepositories))
+	}
+	for j, gitRepository := range gitRepositories {
+		templateData := resolveRepositoryTemplateData(config, processingContext, gitRepository)
+		for i := range result {
+			status, err := resolveTemplateEntryStatus(config, processingContext, i, gitRepository, templateData, installManifests[j])
+			if err != nil {
+				return nil, fmt.Errorf("error resolving status of %q in %q:\n%w", processingContext.GetTemplateEntryIdentifier(i), gitRepository, err)
+			}
+			result[i][j] = status
+		}
+	}
+	return &result, nil
+}
+
+// Single status when it's shared by all repositories, otherwise counts, e.g. "2 identical, 1 outdated"
+func formatTemplateEntryStatuses(statuses []TemplateEntryStatus) string {
+	statusCounts := make(map[TemplateEntryStatus]int)
+	for _, status := range statuses {
+		statusCounts[status]++
+	}
+	var formattedStatuses []string
+	for _, status := range []TemplateEntryStatus{TemplateEntryStatusNew, TemplateEntryStatusIdentical, TemplateEntryStatusModified, TemplateEntryStatusOutdated, TemplateEntryStatusDiffers} {
+		count, ok := statusCounts[status]
+		if !ok {
+			continue
+		}
+		formattedStatus := fmt.Sprintf("%s%s%s", status.Color(), status, utils.Reset)
+		if len(statusCounts) > 1 {
+			formattedStatus = fmt.Sprintf("%d %s", count, formattedStatus)
+		}
+		formattedStatuses = append(formattedStatuses, formattedStatus)
+	}
+	return strings.Join(formattedStatuses, ", ")
+}
+
+// Changes of repositories with differing copies, from template to repository copy
+func resolveTemplateEntryDiff(config Config, processingContext ProcessingContext, repositoryFragmentContext RepositoryFragmentContext, i int) (string, error) {
+	result := ""
+	for j, gitRepository := range repositoryFragmentContext.InputGitRepositories {
+		status := repositoryFragmentContext.TemplateDirectoryStatuses[i][j]
+		if !status.IsConflicting() && status != TemplateEntryStatusOutdated {
+			continue
+		}
+		templateContents, err := resolveTemplateEntryContents(config, processingContext, i, resolveRepositoryTemplateData(config, processingContext, gitRepository))
+		if err != nil {
+			return "", err
+		}
+		destinationFile := filepath.Join(gitRepository, config.TargetFolder, processingContext.TemplateDirectoryTargetNames[i])
+		destinationContents, err := os.ReadFile(destinationFile)
+		if err != nil {
+			return "", err
+		}
+		result += fmt.Sprintf("%s%s%s [%s%s%s]\n", utils.FontBold, gitRepository, utils.Reset, status.Color(), status, utils.Reset)
+		result += utils.UnifiedDiff(processingContext.GetTemplateEntrySourceFile(i), destinationFile, string(templateContents), string(destinationContents))
+	}
+	if result == "" {
+		return "No differences between template and repository copies\n", nil
+	}
+	return result, nil
+}
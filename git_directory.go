@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/koniferous22/dot-user-git-util/utils"
+)
+
+// Contents of ".git" file in linked worktrees and submodules, e.g. "gitdir: ../.git/modules/lib"
+const GitdirFilePrefix = "gitdir:"
+
+// File in git directory of linked worktree, pointing to git directory shared by all worktrees
+const GitCommonDirFileName = "commondir"
+
+const (
+	WorktreeTargetOwn    = "own"
+	WorktreeTargetShared = "shared"
+)
+
+func readGitPathFile(filePath string, prefix string) (string, error) {
+	contents, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	path, ok := strings.CutPrefix(strings.TrimSpace(string(contents)), prefix)
+	path = strings.TrimSpace(path)
+	if !ok || path == "" {
+		return "", fmt.Errorf("unexpected contents of %q, expected \"%s<path>\"", filePath, prefix)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(filePath), path)
+	}
+	return filepath.Clean(path), nil
+}
+
+// ".git" is either the git directory, or a file pointing to it in case of linked worktrees and submodules
+func resolveGitDirectory(gitRepository string) (string, error) {
+	dotGitPath := filepath.Join(gitRepository, DotGitDirectory)
+	fileInfo, err := os.Stat(dotGitPath)
+	if err != nil {
+		return "", err
+	}
+	if fileInfo.IsDir() {
+		return dotGitPath, nil
+	}
+	gitDirectory, err := readGitPathFile(dotGitPath, GitdirFilePrefix)
+	if err != nil {
+		return "", err
+	}
+	if ok, err := utils.ValidateDirectoryExists(gitDirectory); !ok {
+		if err != nil {
+			return "", fmt.Errorf("git directory %q referenced by %q doesn't exist\n%w", gitDirectory, dotGitPath, err)
+		}
+		return "", fmt.Errorf("git directory %q referenced by %q is not a directory", gitDirectory, dotGitPath)
+	}
+	return gitDirectory, nil
+}
+
+// Same as git directory, except for linked worktrees
+func resolveGitCommonDirectory(gitRepository string) (string, error) {
+	gitDirectory, err := resolveGitDirectory(gitRepository)
+	if err != nil {
+		return "", err
+	}
+	commonDirectory, err := readGitPathFile(filepath.Join(gitDirectory, GitCommonDirFileName), "")
+	if os.IsNotExist(err) {
+		return gitDirectory, nil
+	}
+	return commonDirectory, err
+}
+
+// Empty for main worktrees, submodules and worktrees of bare repositories
+func resolveMainWorktree(gitRepository string) (string, error) {
+	gitDirectory, err := resolveGitDirectory(gitRepository)
+	if err != nil {
+		return "", err
+	}
+	commonDirectory, err := resolveGitCommonDirectory(gitRepository)
+	if err != nil {
+		return "", err
+	}
+	if commonDirectory == gitDirectory || filepath.Base(commonDirectory) != DotGitDirectory {
+		return "", nil
+	}
+	return filepath.Dir(commonDirectory), nil
+}
+
+// With shared target, linked worktrees are replaced by their main worktree
+func resolveSharedWorktrees(gitRepositories []string) ([]string, error) {
+	var result []string
+	for _, gitRepository := range gitRepositories {
+		mainWorktree, err := resolveMainWorktree(gitRepository)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving main worktree of %q\n%w", gitRepository, err)
+		}
+		if mainWorktree == "" {
+			mainWorktree = gitRepository
+		} else {
+			fmt.Fprintf(os.Stderr, "%sUsing target directory of main worktree %q for %q%s\n", utils.ColorGreen, mainWorktree, gitRepository, utils.Reset)
+		}
+		if !slices.Contains(result, mainWorktree) {
+			result = append(result, mainWorktree)
+		}
+	}
+	return result, nil
+}
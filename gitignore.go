@@ -3,55 +3,240 @@ package main
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
+	"github.com/koniferous22/dot-user-git-util/gitignore"
 	"github.com/koniferous22/dot-user-git-util/utils"
 )
 
+const (
+	// ".gitignore" in repository root, shared with everyone working on the repository
+	IgnoreTargetGitignore = "gitignore"
+	// "info/exclude" in git directory, private to the clone
+	IgnoreTargetExclude = "exclude"
+	// "core.excludesFile" from git config, private to the user and applied to all repositories
+	IgnoreTargetGlobal = "global"
+)
+
+var IgnoreTargets = []string{IgnoreTargetGitignore, IgnoreTargetExclude, IgnoreTargetGlobal}
+
+var IgnoreTargetDescriptions = map[string]string{
+	IgnoreTargetGitignore: ".gitignore (shared with collaborators)",
+	IgnoreTargetExclude:   ".git/info/exclude (private to the clone)",
+	IgnoreTargetGlobal:    "global excludes file (private, applies to all repositories)",
+}
+
 func GetGitignorePattern(targetFolder string) string {
 	return fmt.Sprintf("%s/", targetFolder)
 }
 
-func GetGitignorePresence(gitRepositoryPaths []string, targetFolder string) (*[]bool, error) {
-	result := make([]bool, len(gitRepositoryPaths))
-	for i, gitRepositoryPath := range gitRepositoryPaths {
-		gitignorePath := filepath.Join(gitRepositoryPath, ".gitignore")
-		directoryGitignored, err := utils.ValidateLineInFile(gitignorePath, GetGitignorePattern(targetFolder))
+func getGlobalExcludesFilePath(gitRepositoryPath string) (string, error) {
+	if excludesFile := runGitQuery(gitRepositoryPath, "config", "--path", "core.excludesFile"); excludesFile != "" {
+		return excludesFile, nil
+	}
+	// Default location used by git, when "core.excludesFile" isn't configured
+	if xdgConfigHome := os.Getenv("XDG_CONFIG_HOME"); xdgConfigHome != "" {
+		return filepath.Join(xdgConfigHome, "git", "ignore"), nil
+	}
+	homeDirectory, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDirectory, ".config", "git", "ignore"), nil
+}
+
+func GetIgnoreFilePath(gitRepositoryPath string, ignoreTarget string) (string, error) {
+	switch ignoreTarget {
+	case IgnoreTargetGitignore:
+		return filepath.Join(gitRepositoryPath, ".gitignore"), nil
+	case IgnoreTargetExclude:
+		gitCommonDirectory, err := resolveGitCommonDirectory(gitRepositoryPath)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(gitCommonDirectory, "info", "exclude"), nil
+	case IgnoreTargetGlobal:
+		return getGlobalExcludesFilePath(gitRepositoryPath)
+	}
+	return "", fmt.Errorf("unknown ignore target %q", ignoreTarget)
+}
+
+// Ignore targets containing managed block, or the pattern appended by previous versions, in order of "IgnoreTargets"
+func getManagedIgnoreTargets(gitRepositoryPath string, targetFolder string) ([]string, error) {
+	pattern := GetGitignorePattern(targetFolder)
+	var result []string
+	for _, ignoreTarget := range IgnoreTargets {
+		ignoreFilePath, err := GetIgnoreFilePath(gitRepositoryPath, ignoreTarget)
+		if err != nil {
+			return nil, err
+		}
+		managedBlockFound, err := managedBlockContainsTarget(ignoreFilePath, targetFolder)
+		if err != nil {
+			return nil, err
+		}
+		patternFound, err := utils.ValidateLineInFile(ignoreFilePath, pattern)
 		if err != nil && !os.IsNotExist(err) {
 			return nil, err
 		}
-		result[i] = directoryGitignored
+		if managedBlockFound || patternFound {
+			result = append(result, ignoreTarget)
+		}
 	}
-	return &result, nil
+	return result, nil
 }
-func GitignoreWritePattern(gitRepositoryPath, pattern string) error {
-	gitignorePath := filepath.Join(gitRepositoryPath, ".gitignore")
-	file, err := os.OpenFile(gitignorePath, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
-	if err != nil {
-		return err
+
+// Ignore targets with managed block containing patterns of target directory, lines written by hand (or by previous versions) aren't considered
+func getManagedBlockIgnoreTargets(gitRepositoryPath string, targetFolder string) ([]string, error) {
+	var result []string
+	for _, ignoreTarget := range IgnoreTargets {
+		ignoreFilePath, err := GetIgnoreFilePath(gitRepositoryPath, ignoreTarget)
+		if err != nil {
+			return nil, err
+		}
+		managedBlockFound, err := managedBlockContainsTarget(ignoreFilePath, targetFolder)
+		if err != nil {
+			return nil, err
+		}
+		if managedBlockFound {
+			result = append(result, ignoreTarget)
+		}
 	}
-	defer file.Close()
-	// Stat for file-size
-	fileInfo, err := file.Stat()
+	return result, nil
+}
+
+func getManagedIgnoreFilePaths(gitRepositoryPath string, targetFolder string) ([]string, error) {
+	ignoreTargets, err := getManagedIgnoreTargets(gitRepositoryPath, targetFolder)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	result := make([]string, len(ignoreTargets))
+	for i, ignoreTarget := range ignoreTargets {
+		if result[i], err = GetIgnoreFilePath(gitRepositoryPath, ignoreTarget); err != nil {
+			return nil, err
+		}
 	}
-	if fileInfo.Size() > 0 {
-		lastByte := make([]byte, 1)
-		_, err := file.ReadAt(lastByte, fileInfo.Size()-1)
+	return result, nil
+}
+
+// Private entries are ignored by individual patterns, so target directory itself isn't
+// Fills sources of repositories, where target directory isn't ignored as a whole, with the first ignore file containing managed block
+func resolvePrivateEntryIgnoreSources(gitRepositoryPaths []string, targetFolder string, ignoreSources []string) error {
+	for i, gitRepositoryPath := range gitRepositoryPaths {
+		if ignoreSources[i] != "" {
+			continue
+		}
+		managedIgnoreFilePaths, err := getManagedIgnoreFilePaths(gitRepositoryPath, targetFolder)
 		if err != nil {
 			return err
 		}
-		// Fix newline before appending phrase
-		if lastByte[0] != '\n' {
-			if _, err := file.WriteString("\n"); err != nil {
-				return err
-			}
+		if len(managedIgnoreFilePaths) > 0 {
+			ignoreSources[i] = fmt.Sprintf("managed block of %s", managedIgnoreFilePaths[0])
+		}
+	}
+	return nil
+}
+
+// Ignore targets ordered by increasing precedence, as evaluated by git
+var ignoreTargetPrecedence = []string{IgnoreTargetGlobal, IgnoreTargetExclude, IgnoreTargetGitignore}
+
+func loadIgnorePatterns(ignoreFilePath string, source string, base string) ([]gitignore.Pattern, error) {
+	contents, err := os.ReadFile(ignoreFilePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return gitignore.ParsePatterns(string(contents), source, base), nil
+}
+
+// Mirrors precedence of git - global excludes file, "info/exclude", then ".gitignore" files from repository root down to target directory
+func loadIgnoreMatcher(gitRepositoryPath string, targetFolder string) (*gitignore.Matcher, error) {
+	var patterns []gitignore.Pattern
+	for _, ignoreTarget := range ignoreTargetPrecedence {
+		ignoreFilePath, err := GetIgnoreFilePath(gitRepositoryPath, ignoreTarget)
+		if err != nil {
+			return nil, err
+		}
+		source := ignoreFilePath
+		if ignoreTarget == IgnoreTargetGitignore {
+			source = ".gitignore"
+		}
+		ignoreTargetPatterns, err := loadIgnorePatterns(ignoreFilePath, source, "")
+		if err != nil {
+			return nil, err
 		}
+		patterns = append(patterns, ignoreTargetPatterns...)
 	}
-	if _, err := file.WriteString(pattern + "\n"); err != nil {
-		return err
+	base := ""
+	for _, component := range strings.Split(targetFolder, "/") {
+		base = path.Join(base, component)
+		nestedGitignore := path.Join(base, ".gitignore")
+		nestedPatterns, err := loadIgnorePatterns(filepath.Join(gitRepositoryPath, filepath.FromSlash(nestedGitignore)), nestedGitignore, base)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, nestedPatterns...)
 	}
+	matcher := gitignore.NewMatcher(patterns, runGitQuery(gitRepositoryPath, "config", "--bool", "core.ignoreCase") == "true")
+	return &matcher, nil
+}
 
-	return nil
+// Pattern that made target directory ignored, formatted as "<source>:<line>:<pattern>", empty when target directory isn't ignored
+func ResolveTargetIgnoreSources(gitRepositoryPaths []string, targetFolder string) ([]string, error) {
+	targetFolder = filepath.ToSlash(filepath.Clean(targetFolder))
+	result := make([]string, len(gitRepositoryPaths))
+	for i, gitRepositoryPath := range gitRepositoryPaths {
+		matcher, err := loadIgnoreMatcher(gitRepositoryPath, targetFolder)
+		if err != nil {
+			return nil, err
+		}
+		if ignored, pattern := matcher.IsDirectoryIgnored(targetFolder); ignored {
+			result[i] = pattern.String()
+		}
+	}
+	return result, nil
+}
+
+// Removes lines matching the pattern appended by previous versions, rest of the file is kept untouched
+func removePatternFromFile(filePath string, pattern string) (bool, error) {
+	fileInfo, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	contents, err := os.ReadFile(filePath)
+	if err != nil {
+		return false, err
+	}
+	var result strings.Builder
+	removed := false
+	for _, line := range strings.SplitAfter(string(contents), "\n") {
+		if strings.TrimRight(line, "\r\n") == pattern {
+			removed = true
+			continue
+		}
+		result.WriteString(line)
+	}
+	if !removed {
+		return false, nil
+	}
+	return true, os.WriteFile(filePath, []byte(result.String()), fileInfo.Mode().Perm())
+}
+
+func IgnoreTargetRemovePattern(gitRepositoryPath string, ignoreTarget string, targetFolder string) (bool, error) {
+	ignoreFilePath, err := GetIgnoreFilePath(gitRepositoryPath, ignoreTarget)
+	if err != nil {
+		return false, err
+	}
+	blockRemoved, err := removeManagedBlockTarget(ignoreFilePath, targetFolder)
+	if err != nil {
+		return false, err
+	}
+	patternRemoved, err := removePatternFromFile(ignoreFilePath, GetGitignorePattern(targetFolder))
+	return blockRemoved || patternRemoved, err
 }
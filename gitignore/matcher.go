@@ -0,0 +1,56 @@
+package gitignore
+
+import (
+	"strings"
+)
+
+// Patterns are evaluated in order, last matching pattern decides
+type Matcher struct {
+	Patterns []Pattern
+}
+
+// Case of paths is ignored when "core.ignoreCase" is set, same as git does on case-insensitive file systems
+func NewMatcher(patterns []Pattern, ignoreCase bool) Matcher {
+	if !ignoreCase {
+		return Matcher{Patterns: patterns}
+	}
+	foldedPatterns := make([]Pattern, len(patterns))
+	for i, pattern := range patterns {
+		foldedPatterns[i] = pattern.withIgnoreCase()
+	}
+	return Matcher{Patterns: foldedPatterns}
+}
+
+func (matcher Matcher) match(path string, isDirectory bool) *Pattern {
+	for i := len(matcher.Patterns) - 1; i >= 0; i-- {
+		if matcher.Patterns[i].Match(path, isDirectory) {
+			return &matcher.Patterns[i]
+		}
+	}
+	return nil
+}
+
+// Paths in ignored directory can't be re-included, as git doesn't descend into ignored directories
+// Returns pattern that made the path ignored
+func (matcher Matcher) IsIgnored(path string, isDirectory bool) (bool, *Pattern) {
+	components := strings.Split(strings.Trim(path, "/"), "/")
+	for i := range components {
+		isLast := i == len(components)-1
+		pattern := matcher.match(strings.Join(components[:i+1], "/"), !isLast || isDirectory)
+		if pattern != nil && !pattern.Negated {
+			return true, pattern
+		}
+	}
+	return false, nil
+}
+
+// Directory is effectively ignored when either itself, or any new file created inside is ignored (e.g. "dir/**")
+func (matcher Matcher) IsDirectoryIgnored(path string) (bool, *Pattern) {
+	if ignored, pattern := matcher.IsIgnored(path, true); ignored {
+		return true, pattern
+	}
+	return matcher.IsIgnored(strings.Trim(path, "/")+"/"+directoryContentsProbe, false)
+}
+
+// Name of hypothetical file, used to check whether contents of directory are ignored
+const directoryContentsProbe = "dot-user-git-util-probe"
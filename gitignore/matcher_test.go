@@ -0,0 +1,146 @@
+package gitignore
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type matcherTestCase struct {
+	patterns    string
+	path        string
+	isDirectory bool
+	ignoreCase  bool
+	ignored     bool
+}
+
+var matcherTestCases = []matcherTestCase{
+	{patterns: "*.log", path: "debug.log", ignored: true},
+	{patterns: "*.log", path: "logs/debug.log", ignored: true},
+	{patterns: "*.log\n!keep.log", path: "keep.log"},
+	{patterns: "/build", path: "build", isDirectory: true, ignored: true},
+	{patterns: "/build", path: "src/build", isDirectory: true},
+	{patterns: "build/", path: "build"},
+	{patterns: "build/", path: "build", isDirectory: true, ignored: true},
+	{patterns: "build/", path: "build/output.txt", ignored: true},
+	{patterns: "doc/*.txt", path: "doc/notes.txt", ignored: true},
+	{patterns: "doc/*.txt", path: "doc/server/notes.txt"},
+	{patterns: "**/foo", path: "a/b/foo", ignored: true},
+	{patterns: "a/**/b", path: "a/b", ignored: true},
+	{patterns: "a/**/b", path: "a/x/y/b", ignored: true},
+	{patterns: "abc/**", path: "abc/x/y", ignored: true},
+	{patterns: "foo?", path: "foo1", ignored: true},
+	{patterns: "foo?", path: "foo"},
+	{patterns: "\\#comment", path: "#comment", ignored: true},
+	{patterns: "\\!important", path: "!important", ignored: true},
+	{patterns: "trailing\\ ", path: "trailing ", ignored: true},
+	{patterns: "dir/\n!dir/keep", path: "dir/keep", ignored: true},
+	{patterns: "[abc].txt", path: "b.txt", ignored: true},
+	{patterns: "[abc].txt", path: "d.txt"},
+	{patterns: "[!abc].txt", path: "d.txt", ignored: true},
+	{patterns: "[a-c].txt", path: "c.txt", ignored: true},
+	{patterns: "[]].txt", path: "].txt", ignored: true},
+	{patterns: "[[:alpha:]].txt", path: "x.txt", ignored: true},
+	{patterns: "[[:alpha:]].txt", path: "1.txt"},
+	{patterns: "[[:digit:]].txt", path: "7.txt", ignored: true},
+	{patterns: "[[:alnum:]_]*", path: "_tmp", ignored: true},
+	{patterns: "[[:upper:]]*", path: "Makefile", ignored: true},
+	{patterns: "[[:upper:]]*", path: "makefile"},
+	{patterns: "[[:lower:]]*", path: "makefile", ignored: true},
+	{patterns: "[[:space:]]*", path: " x", ignored: true},
+	{patterns: "[[:punct:]]*", path: "~backup", ignored: true},
+	{patterns: "[[:xdigit:]].bin", path: "f.bin", ignored: true},
+	{patterns: "[[:xdigit:]].bin", path: "g.bin"},
+	{patterns: "[![:digit:]].txt", path: "a.txt", ignored: true},
+	{patterns: "[![:digit:]].txt", path: "1.txt"},
+	{patterns: "[[:unknown:]].txt", path: "a.txt"},
+	{patterns: "[[:alpha].txt", path: "[.txt", ignored: true},
+	{patterns: "*.LOG", path: "debug.log"},
+	{patterns: "*.LOG", path: "debug.log", ignoreCase: true, ignored: true},
+	{patterns: "Build/", path: "build", isDirectory: true, ignoreCase: true, ignored: true},
+	{patterns: "[[:upper:]]*", path: "makefile", ignoreCase: true, ignored: true},
+	{patterns: "*.log\n!KEEP.log", path: "keep.log", ignoreCase: true},
+}
+
+func TestMatcherIsIgnored(t *testing.T) {
+	for _, testCase := range matcherTestCases {
+		matcher := NewMatcher(ParsePatterns(testCase.patterns, ".gitignore", ""), testCase.ignoreCase)
+		if ignored, _ := matcher.IsIgnored(testCase.path, testCase.isDirectory); ignored != testCase.ignored {
+			t.Errorf("patterns %q, path %q, ignoreCase %v: got ignored %v, want %v", testCase.patterns, testCase.path, testCase.ignoreCase, ignored, testCase.ignored)
+		}
+	}
+}
+
+func TestMatcherNestedBase(t *testing.T) {
+	matcher := NewMatcher(ParsePatterns("*.tmp", "sub/.gitignore", "sub"), false)
+	if ignored, _ := matcher.IsIgnored("sub/a.tmp", false); !ignored {
+		t.Errorf("expected \"sub/a.tmp\" to be ignored")
+	}
+	if ignored, _ := matcher.IsIgnored("a.tmp", false); ignored {
+		t.Errorf("expected \"a.tmp\" not to be ignored by nested ignore file")
+	}
+	foldedMatcher := NewMatcher(ParsePatterns("*.tmp", "sub/.gitignore", "sub"), true)
+	if ignored, _ := foldedMatcher.IsIgnored("SUB/a.tmp", false); !ignored {
+		t.Errorf("expected \"SUB/a.tmp\" to be ignored when ignoring case")
+	}
+}
+
+func TestMatcherDirectoryContents(t *testing.T) {
+	matcher := NewMatcher(ParsePatterns(".me/**", ".gitignore", ""), false)
+	if ignored, _ := matcher.IsIgnored(".me", true); ignored {
+		t.Errorf("expected \".me\" itself not to be ignored")
+	}
+	if ignored, pattern := matcher.IsDirectoryIgnored(".me"); !ignored || pattern.String() != ".gitignore:1:.me/**" {
+		t.Errorf("expected contents of \".me\" to be ignored by \".gitignore:1:.me/**\", got %v %v", ignored, pattern)
+	}
+}
+
+// Same cases are checked with "git check-ignore", so that the table itself doesn't drift from git behaviour
+func TestMatcherAgainstGitCheckIgnore(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	for _, testCase := range matcherTestCases {
+		repository := t.TempDir()
+		runGit(t, repository, "init", "-q")
+		runGit(t, repository, "config", "core.ignoreCase", map[bool]string{true: "true", false: "false"}[testCase.ignoreCase])
+		if err := os.WriteFile(filepath.Join(repository, ".gitignore"), []byte(testCase.patterns+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(repository, filepath.FromSlash(testCase.path))
+		if testCase.isDirectory {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				t.Fatal(err)
+			}
+		} else {
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		command := exec.Command("git", "-C", repository, "check-ignore", "-q", "--no-index", testCase.path)
+		command.Env = append(os.Environ(), "GIT_CONFIG_NOSYSTEM=1", "GIT_CONFIG_GLOBAL="+os.DevNull)
+		err := command.Run()
+		var exitError *exec.ExitError
+		if err != nil && !(errors.As(err, &exitError) && exitError.ExitCode() == 1) {
+			t.Fatalf("git check-ignore %q failed: %v", testCase.path, err)
+		}
+		if gitIgnored := err == nil; gitIgnored != testCase.ignored {
+			t.Errorf("patterns %q, path %q, ignoreCase %v: git reports ignored %v, table expects %v", testCase.patterns, testCase.path, testCase.ignoreCase, gitIgnored, testCase.ignored)
+		}
+	}
+}
+
+func runGit(t *testing.T, repository string, args ...string) {
+	t.Helper()
+	command := exec.Command("git", append([]string{"-C", repository}, args...)...)
+	command.Env = append(os.Environ(), "GIT_CONFIG_NOSYSTEM=1", "GIT_CONFIG_GLOBAL="+os.DevNull)
+	if output, err := command.CombinedOutput(); err != nil {
+		t.Fatalf("git %s failed: %v\n%s", strings.Join(args, " "), err, output)
+	}
+}
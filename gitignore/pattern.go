@@ -0,0 +1,204 @@
+package gitignore
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+type Pattern struct {
+	// Line as found in the ignore file
+	Raw    string
+	Line   int
+	Source string
+	// Directory of ignore file relative to repository root, separated by "/", patterns apply only to paths inside
+	Base string
+	// Pattern prefixed with "!" re-includes paths excluded by previous patterns
+	Negated bool
+	// Pattern suffixed with "/" matches only directories
+	DirectoryOnly bool
+	// Pattern containing "/" (except trailing) is matched against whole path, otherwise against any path component
+	Anchored bool
+	// Paths are matched case-insensitively, as with "core.ignoreCase"
+	IgnoreCase bool
+	expression string
+	regexp     *regexp.Regexp
+}
+
+// Trailing spaces are ignored, unless escaped with backslash
+func trimTrailingSpaces(line string) string {
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	return line
+}
+
+// Returns false for blank lines, comments and patterns that can't be compiled
+func ParsePattern(line string, lineNumber int) (Pattern, bool) {
+	pattern := Pattern{Raw: line, Line: lineNumber}
+	line = trimTrailingSpaces(strings.TrimSuffix(line, "\r"))
+	if line == "" || strings.HasPrefix(line, "#") {
+		return pattern, false
+	}
+	if strings.HasPrefix(line, "!") {
+		pattern.Negated = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") && !strings.HasSuffix(line, "\\/") {
+		pattern.DirectoryOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return pattern, false
+	}
+	pattern.Anchored = strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	expression, err := translateGlob(line)
+	if err != nil {
+		return pattern, false
+	}
+	pattern.expression = "^" + expression + "$"
+	compiled, err := regexp.Compile(pattern.expression)
+	if err != nil {
+		return pattern, false
+	}
+	pattern.regexp = compiled
+	return pattern, true
+}
+
+// Copy of the pattern matching paths regardless of case
+func (pattern Pattern) withIgnoreCase() Pattern {
+	pattern.IgnoreCase = true
+	pattern.regexp = regexp.MustCompile("(?i)" + pattern.expression)
+	return pattern
+}
+
+func ParsePatterns(contents string, source string, base string) []Pattern {
+	var patterns []Pattern
+	contents = strings.TrimPrefix(contents, "\ufeff")
+	for i, line := range strings.Split(contents, "\n") {
+		if pattern, ok := ParsePattern(line, i+1); ok {
+			pattern.Source = source
+			pattern.Base = base
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// Same format as "git check-ignore -v"
+func (pattern Pattern) String() string {
+	return fmt.Sprintf("%s:%d:%s", pattern.Source, pattern.Line, strings.TrimSuffix(pattern.Raw, "\r"))
+}
+
+// Path is relative to repository root, separated by "/"
+func (pattern Pattern) Match(path string, isDirectory bool) bool {
+	if pattern.DirectoryOnly && !isDirectory {
+		return false
+	}
+	if pattern.Base != "" {
+		prefix := pattern.Base + "/"
+		if len(path) < len(prefix) || path[:len(prefix)] != prefix && !(pattern.IgnoreCase && strings.EqualFold(path[:len(prefix)], prefix)) {
+			return false
+		}
+		path = path[len(prefix):]
+	}
+	if !pattern.Anchored {
+		path = path[strings.LastIndex(path, "/")+1:]
+	}
+	return pattern.regexp.MatchString(path)
+}
+
+// Translates glob to regular expression, "*", "?" and brackets don't match "/", "**" matches across directories
+func translateGlob(glob string) (string, error) {
+	var result strings.Builder
+	runes := []rune(glob)
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+			}
+			result.WriteString(regexp.QuoteMeta(string(runes[i])))
+		case '*':
+			if i+1 < len(runes) && runes[i+1] == '*' {
+				atSegmentStart := i == 0 || runes[i-1] == '/'
+				atSegmentEnd := i+2 == len(runes) || runes[i+2] == '/'
+				if atSegmentStart && atSegmentEnd {
+					if i+2 == len(runes) {
+						// Trailing "/**" matches everything inside
+						result.WriteString(".*")
+					} else {
+						// Leading "**/" and inner "/**/" match zero or more directories
+						result.WriteString("(?:.*/)?")
+					}
+					i += 2
+					continue
+				}
+				for i+1 < len(runes) && runes[i+1] == '*' {
+					i++
+				}
+			}
+			result.WriteString("[^/]*")
+		case '?':
+			result.WriteString("[^/]")
+		case '[':
+			bracketExpression, length, err := translateBracketExpression(runes[i:])
+			if err != nil {
+				return "", err
+			}
+			if length == 0 {
+				result.WriteString(regexp.QuoteMeta("["))
+				continue
+			}
+			result.WriteString(bracketExpression)
+			i += length - 1
+		default:
+			result.WriteString(regexp.QuoteMeta(string(runes[i])))
+		}
+	}
+	return result.String(), nil
+}
+
+// Character classes supported by git within bracket expressions, e.g. "[[:alpha:]]", all of them are known to regexp package
+var bracketCharacterClasses = []string{"alnum", "alpha", "blank", "cntrl", "digit", "graph", "lower", "print", "punct", "space", "upper", "xdigit"}
+
+// Returns zero length when bracket expression isn't closed, so that "[" is matched literally
+func translateBracketExpression(runes []rune) (string, int, error) {
+	var result strings.Builder
+	result.WriteString("[")
+	i := 1
+	if i < len(runes) && (runes[i] == '!' || runes[i] == '^') {
+		result.WriteString("^/")
+		i++
+	}
+	for start := i; i < len(runes); i++ {
+		switch {
+		case runes[i] == ']' && i > start:
+			result.WriteString("]")
+			return result.String(), i + 1, nil
+		case runes[i] == '[' && i+1 < len(runes) && runes[i+1] == ':':
+			// Without closing ":]" the "[" is matched literally
+			classLength := slices.Index(runes[i:], ']')
+			if classLength < 0 || classLength < 3 || runes[i+classLength-1] != ':' {
+				result.WriteString(regexp.QuoteMeta("["))
+				continue
+			}
+			className := string(runes[i+2 : i+classLength-1])
+			if !slices.Contains(bracketCharacterClasses, className) {
+				return "", 0, fmt.Errorf("unknown character class %q", className)
+			}
+			result.WriteString("[:" + className + ":]")
+			i += classLength
+		case runes[i] == '\\' && i+1 < len(runes):
+			i++
+			result.WriteString(regexp.QuoteMeta(string(runes[i])))
+		case runes[i] == '-' && i > start && i+1 < len(runes) && runes[i+1] != ']':
+			result.WriteString("-")
+		default:
+			result.WriteString(regexp.QuoteMeta(string(runes[i])))
+		}
+	}
+	return "", 0, nil
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/koniferous22/dot-user-git-util/utils"
+)
+
+// Delimits lines owned by the util in ignore files, contents are replaced as a whole on every write
+const (
+	ManagedBlockStart = "# >>> dot-user-git-util >>>"
+	ManagedBlockEnd   = "# <<< dot-user-git-util <<<"
+)
+
+const utf8ByteOrderMark = "\ufeff"
+
+type ignoreFileContents struct {
+	byteOrderMark string
+	// Including original line endings, so that lines outside of managed block are written back untouched
+	lines []string
+	// Used for written lines, detected from the first line
+	lineEnding string
+	mode       os.FileMode
+}
+
+func readIgnoreFile(filePath string) (*ignoreFileContents, error) {
+	ignoreFile := &ignoreFileContents{lineEnding: "\n", mode: 0644}
+	fileInfo, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
+		return ignoreFile, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ignoreFile.mode = fileInfo.Mode().Perm()
+	contents, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	text := string(contents)
+	if strings.HasPrefix(text, utf8ByteOrderMark) {
+		ignoreFile.byteOrderMark = utf8ByteOrderMark
+		text = strings.TrimPrefix(text, utf8ByteOrderMark)
+	}
+	if text != "" {
+		ignoreFile.lines = strings.SplitAfter(text, "\n")
+		if ignoreFile.lines[len(ignoreFile.lines)-1] == "" {
+			ignoreFile.lines = ignoreFile.lines[:len(ignoreFile.lines)-1]
+		}
+		if strings.HasSuffix(ignoreFile.lines[0], "\r\n") {
+			ignoreFile.lineEnding = "\r\n"
+		}
+	}
+	return ignoreFile, nil
+}
+
+func (ignoreFile ignoreFileContents) write(filePath string) error {
+	if err := utils.EnsureDirectoryExists(filepath.Dir(filePath)); err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, []byte(ignoreFile.byteOrderMark+strings.Join(ignoreFile.lines, "")), ignoreFile.mode)
+}
+
+// Indices of start and end marker lines, negative when block isn't found
+func (ignoreFile ignoreFileContents) findManagedBlock(filePath string) (int, int, error) {
+	start, end := -1, -1
+	for i, line := range ignoreFile.lines {
+		switch strings.TrimRight(line, "\r\n") {
+		case ManagedBlockStart:
+			if start >= 0 {
+				return -1, -1, fmt.Errorf("multiple managed blocks found in %q", filePath)
+			}
+			start = i
+		case ManagedBlockEnd:
+			if start < 0 || end >= 0 {
+				return -1, -1, fmt.Errorf("unexpected %q in %q", ManagedBlockEnd, filePath)
+			}
+			end = i
+		}
+	}
+	if start >= 0 && end < 0 {
+		return -1, -1, fmt.Errorf("managed block in %q is missing %q", filePath, ManagedBlockEnd)
+	}
+	return start, end, nil
+}
+
+// Patterns in managed block, nil when block isn't found
+func readManagedBlock(filePath string) ([]string, error) {
+	ignoreFile, err := readIgnoreFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := ignoreFile.findManagedBlock(filePath)
+	if err != nil || start < 0 {
+		return nil, err
+	}
+	patterns := make([]string, 0, end-start-1)
+	for _, line := range ignoreFile.lines[start+1 : end] {
+		patterns = append(patterns, strings.TrimRight(line, "\r\n"))
+	}
+	return patterns, nil
+}
+
+// Whether pattern applies to target directory or its contents
+func isTargetPattern(pattern string, targetFolder string) bool {
+	return strings.HasPrefix(strings.TrimPrefix(pattern, "/"), GetGitignorePattern(targetFolder))
+}
+
+// Whether managed block contains patterns for target directory or its contents
+func managedBlockContainsTarget(filePath string, targetFolder string) (bool, error) {
+	patterns, err := readManagedBlock(filePath)
+	if err != nil {
+		return false, err
+	}
+	for _, pattern := range patterns {
+		if isTargetPattern(pattern, targetFolder) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Global excludes file is shared by repositories with possibly different target folders, so patterns of other target folders are kept
+func mergeManagedBlockPatterns(filePath string, targetFolder string, patterns []string) ([]string, error) {
+	existingPatterns, err := readManagedBlock(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, existingPattern := range existingPatterns {
+		if !isTargetPattern(existingPattern, targetFolder) {
+			result = append(result, existingPattern)
+		}
+	}
+	return append(result, patterns...), nil
+}
+
+// Replaces contents of managed block, which is appended when not found
+func writeManagedBlock(filePath string, patterns []string) error {
+	ignoreFile, err := readIgnoreFile(filePath)
+	if err != nil {
+		return err
+	}
+	start, end, err := ignoreFile.findManagedBlock(filePath)
+	if err != nil {
+		return err
+	}
+	block := []string{ManagedBlockStart + ignoreFile.lineEnding}
+	for _, pattern := range patterns {
+		block = append(block, pattern+ignoreFile.lineEnding)
+	}
+	block = append(block, ManagedBlockEnd+ignoreFile.lineEnding)
+	if start >= 0 {
+		ignoreFile.lines = append(ignoreFile.lines[:start], append(block, ignoreFile.lines[end+1:]...)...)
+		return ignoreFile.write(filePath)
+	}
+	if lastLine := len(ignoreFile.lines) - 1; lastLine >= 0 && !strings.HasSuffix(ignoreFile.lines[lastLine], "\n") {
+		ignoreFile.lines[lastLine] += ignoreFile.lineEnding
+	}
+	ignoreFile.lines = append(ignoreFile.lines, block...)
+	return ignoreFile.write(filePath)
+}
+
+// Removes patterns of target folder from managed block, block itself is dropped once empty
+func removeManagedBlockTarget(filePath string, targetFolder string) (bool, error) {
+	ignoreFile, err := readIgnoreFile(filePath)
+	if err != nil {
+		return false, err
+	}
+	start, end, err := ignoreFile.findManagedBlock(filePath)
+	if err != nil || start < 0 {
+		return false, err
+	}
+	var remainingLines []string
+	for _, line := range ignoreFile.lines[start+1 : end] {
+		if !isTargetPattern(strings.TrimRight(line, "\r\n"), targetFolder) {
+			remainingLines = append(remainingLines, line)
+		}
+	}
+	if len(remainingLines) == end-start-1 {
+		return false, nil
+	}
+	if len(remainingLines) == 0 {
+		ignoreFile.lines = append(ignoreFile.lines[:start], ignoreFile.lines[end+1:]...)
+	} else {
+		ignoreFile.lines = append(ignoreFile.lines[:start+1], append(remainingLines, ignoreFile.lines[end:]...)...)
+	}
+	return true, ignoreFile.write(filePath)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeTestIgnoreFile(t *testing.T, contents string) string {
+	t.Helper()
+	filePath := filepath.Join(t.TempDir(), ".gitignore")
+	if err := os.WriteFile(filePath, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filePath
+}
+
+func assertIgnoreFileContents(t *testing.T, filePath string, expected string) {
+	t.Helper()
+	contents, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(contents) != expected {
+		t.Errorf("contents of %q = %q, want %q", filePath, contents, expected)
+	}
+}
+
+func TestManagedBlockRoundTrip(t *testing.T) {
+	testCases := []struct {
+		name     string
+		contents string
+		written  string
+		updated  string
+		removed  string
+	}{
+		{
+			name:     "missing file",
+			contents: "",
+			written:  "# >>> dot-user-git-util >>>\n.me/\n# <<< dot-user-git-util <<<\n",
+			updated:  "# >>> dot-user-git-util >>>\n.alice/\n# <<< dot-user-git-util <<<\n",
+			removed:  "",
+		},
+		{
+			name:     "missing final newline",
+			contents: "node_modules",
+			written:  "node_modules\n# >>> dot-user-git-util >>>\n.me/\n# <<< dot-user-git-util <<<\n",
+			updated:  "node_modules\n# >>> dot-user-git-util >>>\n.alice/\n# <<< dot-user-git-util <<<\n",
+			removed:  "node_modules\n",
+		},
+		{
+			name:     "CRLF with byte order mark",
+			contents: "\ufeffnode_modules\r\ndist\r\n",
+			written:  "\ufeffnode_modules\r\ndist\r\n# >>> dot-user-git-util >>>\r\n.me/\r\n# <<< dot-user-git-util <<<\r\n",
+			updated:  "\ufeffnode_modules\r\ndist\r\n# >>> dot-user-git-util >>>\r\n.alice/\r\n# <<< dot-user-git-util <<<\r\n",
+			removed:  "\ufeffnode_modules\r\ndist\r\n",
+		},
+		{
+			name:     "block followed by user lines",
+			contents: "a\r\n# >>> dot-user-git-util >>>\r\n.me/\r\n# <<< dot-user-git-util <<<\r\nb",
+			written:  "a\r\n# >>> dot-user-git-util >>>\r\n.me/\r\n# <<< dot-user-git-util <<<\r\nb",
+			updated:  "a\r\n# >>> dot-user-git-util >>>\r\n.alice/\r\n# <<< dot-user-git-util <<<\r\nb",
+			removed:  "a\r\nb",
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), ".gitignore")
+			if testCase.contents != "" {
+				filePath = writeTestIgnoreFile(t, testCase.contents)
+			}
+			if err := writeManagedBlock(filePath, []string{".me/"}); err != nil {
+				t.Fatal(err)
+			}
+			assertIgnoreFileContents(t, filePath, testCase.written)
+			// Changed target folder replaces the block contents
+			if err := writeManagedBlock(filePath, []string{".alice/"}); err != nil {
+				t.Fatal(err)
+			}
+			assertIgnoreFileContents(t, filePath, testCase.updated)
+			if removed, err := removeManagedBlockTarget(filePath, ".me"); err != nil || removed {
+				t.Errorf("removing stale target folder = %v, %v, want false", removed, err)
+			}
+			if removed, err := removeManagedBlockTarget(filePath, ".alice"); err != nil || !removed {
+				t.Errorf("removing target folder = %v, %v, want true", removed, err)
+			}
+			assertIgnoreFileContents(t, filePath, testCase.removed)
+		})
+	}
+}
+
+func TestManagedBlockMergeAndRemoveTarget(t *testing.T) {
+	filePath := writeTestIgnoreFile(t, "*.swp\n# >>> dot-user-git-util >>>\n.me/\n.bob/\n# <<< dot-user-git-util <<<\n")
+	patterns, err := mergeManagedBlockPatterns(filePath, ".me", []string{".me/.dot-user-git-util.json", ".me/private.sh"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{".bob/", ".me/.dot-user-git-util.json", ".me/private.sh"}; !slices.Equal(patterns, expected) {
+		t.Errorf("merged patterns = %q, want %q", patterns, expected)
+	}
+	if err := writeManagedBlock(filePath, patterns); err != nil {
+		t.Fatal(err)
+	}
+	if removed, err := removeManagedBlockTarget(filePath, ".me"); err != nil || !removed {
+		t.Errorf("removing target folder = %v, %v, want true", removed, err)
+	}
+	assertIgnoreFileContents(t, filePath, "*.swp\n# >>> dot-user-git-util >>>\n.bob/\n# <<< dot-user-git-util <<<\n")
+}
+
+func TestManagedBlockMalformed(t *testing.T) {
+	for _, contents := range []string{
+		"# >>> dot-user-git-util >>>\n.me/\n",
+		"# <<< dot-user-git-util <<<\n",
+		"# >>> dot-user-git-util >>>\n# <<< dot-user-git-util <<<\n# >>> dot-user-git-util >>>\n# <<< dot-user-git-util <<<\n",
+	} {
+		filePath := writeTestIgnoreFile(t, contents)
+		if err := writeManagedBlock(filePath, []string{".me/"}); err == nil {
+			t.Errorf("writing managed block into %q succeeded, want error", contents)
+		}
+		assertIgnoreFileContents(t, filePath, contents)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/koniferous22/dot-user-git-util/utils"
+)
+
+// Record of installed files, kept in target directory
+const InstallManifestFileName = ".dot-user-git-util.json"
+
+// Copies of installed contents (base for three-way merges), kept in git directory so that they're never committed
+const InstallSnapshotDirectoryName = "dot-user-git-util"
+
+const InstallManifestVersion = 1
+
+type InstalledFile struct {
+	Profile     string    `json:"profile"`
+	Source      string    `json:"source"`
+	Hash        string    `json:"hash"`
+	Mode        string    `json:"mode"`
+	InstalledAt time.Time `json:"installedAt"`
+}
+
+type InstallManifest struct {
+	Version int `json:"version"`
+	// Keyed by relative path in target directory
+	Files map[string]InstalledFile `json:"files"`
+	// Whether manifest was found, otherwise installed files have to be detected by probing
+	exists bool
+}
+
+func GetInstallManifestPath(gitRepository string, targetFolder string) string {
+	return filepath.Join(gitRepository, targetFolder, InstallManifestFileName)
+}
+
+// Missing manifest results in empty manifest
+func LoadInstallManifest(gitRepository string, targetFolder string) (*InstallManifest, error) {
+	manifest := &InstallManifest{Version: InstallManifestVersion, Files: make(map[string]InstalledFile)}
+	manifestPath := GetInstallManifestPath(gitRepository, targetFolder)
+	contents, err := os.ReadFile(manifestPath)
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading install manifest %q:\n%w", manifestPath, err)
+	}
+	if err := json.Unmarshal(contents, manifest); err != nil {
+		return nil, fmt.Errorf("error parsing install manifest %q:\n%w", manifestPath, err)
+	}
+	if manifest.Files == nil {
+		manifest.Files = make(map[string]InstalledFile)
+	}
+	manifest.exists = true
+	return manifest, nil
+}
+
+func (manifest InstallManifest) Exists() bool {
+	return manifest.exists
+}
+
+func (manifest InstallManifest) Save(gitRepository string, targetFolder string) error {
+	contents, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFile(GetInstallManifestPath(gitRepository, targetFolder), append(contents, '\n'), 0644)
+}
+
+func getInstallSnapshotDirectory(gitRepository string, targetFolder string) (string, error) {
+	gitDirectory, err := resolveGitDirectory(gitRepository)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(gitDirectory, InstallSnapshotDirectoryName, targetFolder), nil
+}
+
+func getInstallSnapshotPath(gitRepository string, targetFolder string, targetName string) (string, error) {
+	snapshotDirectory, err := getInstallSnapshotDirectory(gitRepository, targetFolder)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(snapshotDirectory, targetName), nil
+}
+
+// Missing snapshot results in nil contents
+func loadInstallSnapshot(gitRepository string, targetFolder string, targetName string) ([]byte, error) {
+	snapshotPath, err := getInstallSnapshotPath(gitRepository, targetFolder, targetName)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := os.ReadFile(snapshotPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return contents, err
+}
+
+func (manifest InstallManifest) RecordInstall(gitRepository string, targetFolder string, targetName string, profile string, sourceFile string, contents []byte, mode os.FileMode) error {
+	manifest.Files[targetName] = InstalledFile{
+		Profile:     profile,
+		Source:      sourceFile,
+		Hash:        utils.HashContents(contents),
+		Mode:        fmt.Sprintf("%#o", mode.Perm()),
+		InstalledAt: time.Now().UTC(),
+	}
+	snapshotPath, err := getInstallSnapshotPath(gitRepository, targetFolder, targetName)
+	if err != nil {
+		return err
+	}
+	if err := utils.EnsureDirectoryExists(filepath.Dir(snapshotPath)); err != nil {
+		return err
+	}
+	return utils.WriteFile(snapshotPath, contents, 0644)
+}
+
+func (manifest InstallManifest) RecordRemoval(gitRepository string, targetFolder string, targetName string) error {
+	delete(manifest.Files, targetName)
+	snapshotPath, err := getInstallSnapshotPath(gitRepository, targetFolder, targetName)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(snapshotPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
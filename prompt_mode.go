@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/koniferous22/dot-user-git-util/prompts"
+	"github.com/mattn/go-isatty"
+)
+
+const (
+	NoTtyModeFail = "fail"
+	NoTtyModeLine = "line"
+)
+
+func isTerminalAvailable() bool {
+	return isatty.IsTerminal(os.Stdin.Fd()) || isatty.IsCygwinTerminal(os.Stdin.Fd())
+}
+
+// Flags that have to be provided, so that no prompt is shown
+func resolveMissingUnattendedFlags(config Config) []string {
+	var missingFlags []string
+	if !config.FlagYesInitialPrompt {
+		missingFlags = append(missingFlags, "--yes")
+	}
+	if !config.HasSelectionFlag() {
+		missingFlags = append(missingFlags, "--select=<entries>, --select-all or --select-preselected")
+	}
+	if !config.FlagGitignoreInclude && !config.FlagGitignoreOmit && config.GitignoreRepositories == "" {
+		missingFlags = append(missingFlags, "--gitignore-yes, --gitignore-no or --gitignore-repos=<globs>")
+	}
+	// Locally modified entries are only found during processing, when other repositories might already be initialized
+	if config.ConflictPolicy == ConflictPolicyPrompt {
+		missingFlags = append(missingFlags, fmt.Sprintf("--conflict=<policy> with one of %q", ConflictResolutions))
+	}
+	return missingFlags
+}
+
+// Resolves whether prompts should fall back to plain line-based input
+func resolveLinePrompts(config Config) (bool, error) {
+	if isTerminalAvailable() {
+		return false, nil
+	}
+	if config.NoTtyMode == NoTtyModeLine {
+		return true, nil
+	}
+	missingFlags := resolveMissingUnattendedFlags(config)
+	if len(missingFlags) == 0 {
+		return false, nil
+	}
+	message := "stdin is not a terminal, unattended operation requires following flags\n"
+	for _, missingFlag := range missingFlags {
+		message += fmt.Sprintf("* %s\n", missingFlag)
+	}
+	message += fmt.Sprintf("alternatively use \"--no-tty=%s\" to answer prompts line by line from stdin", NoTtyModeLine)
+	return false, fmt.Errorf("%s", message)
+}
+
+func runPrompt(processingContext ProcessingContext, model tea.Model) (tea.Model, error) {
+	if processingContext.LinePrompts {
+		return prompts.RunLinePrompt(model)
+	}
+	program := tea.NewProgram(model)
+	return program.Run()
+}
@@ -0,0 +1,149 @@
+package prompts
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/koniferous22/dot-user-git-util/utils"
+)
+
+// Shared across prompts, so that buffered input isn't lost between them
+var lineReader = bufio.NewReader(os.Stdin)
+
+const LineMultiSelectHelpText = "Enter\n" +
+	"* comma-separated numbers or names to toggle selection\n" +
+	"* 'd <number>' to show differences of entry\n" +
+	"* empty line to submit\n" +
+	"* 'q' to quit\n"
+
+// Plain line-based fallback for environments without terminal
+func RunLinePrompt(model tea.Model) (tea.Model, error) {
+	switch model := model.(type) {
+	case YesNoModel:
+		return runYesNoLinePrompt(model)
+	case MultiSelectModel:
+		return runMultiSelectLinePrompt(model)
+	case SelectModel:
+		return runSelectLinePrompt(model)
+	}
+	return nil, fmt.Errorf("line prompt not supported for %T", model)
+}
+
+func readLine() (string, error) {
+	line, err := lineReader.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return "", fmt.Errorf("unexpected end of input")
+	}
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func runYesNoLinePrompt(m YesNoModel) (YesNoModel, error) {
+	for {
+		fmt.Printf("%s%s%s [y/n/q]: ", utils.FontBold, m.Question, utils.Reset)
+		line, err := readLine()
+		if err != nil {
+			return m, err
+		}
+		switch strings.ToLower(line) {
+		case "y", "yes", "":
+			m.Result = true
+			m.Entered = true
+			return m, nil
+		case "n", "no":
+			m.Result = false
+			m.Entered = true
+			m.ShouldExit = m.ShouldQuitOnNo
+			return m, nil
+		case "q", "quit":
+			m.ShouldExit = true
+			return m, nil
+		default:
+			fmt.Printf("%sInvalid Input%s\n", utils.ColorRed, utils.Reset)
+		}
+	}
+}
+
+func runMultiSelectLinePrompt(m MultiSelectModel) (MultiSelectModel, error) {
+	fmt.Printf("%s\n%s", m.HeaderText, LineMultiSelectHelpText)
+	for {
+		for i := range m.Options {
+			if groupHeader, ok := m.groupHeader(i); ok {
+				fmt.Printf("    %s%s%s\n", utils.FontBold, groupHeader, utils.Reset)
+			}
+			checked := "[ ]"
+			if m.Selected[i] {
+				checked = fmt.Sprintf("%s[x]%s", utils.ColorGreen, utils.Reset)
+			}
+			fmt.Printf("%3d %s %s%s%s\n", i+1, checked, m.optionLabel(i), m.annotation(i), m.syncMarker(i))
+		}
+		fmt.Print("> ")
+		line, err := readLine()
+		if err != nil {
+			return m, err
+		}
+		if line == "" {
+			return m, nil
+		}
+		if line == "q" {
+			m.ShouldExit = true
+			return m, nil
+		}
+		if diffArgument, ok := strings.CutPrefix(line, "d "); ok && m.DiffProvider != nil {
+			number, err := strconv.Atoi(strings.TrimSpace(diffArgument))
+			if err != nil || number < 1 || number > len(m.Options) {
+				fmt.Printf("%sInvalid entry %q%s\n", utils.ColorRed, diffArgument, utils.Reset)
+				continue
+			}
+			fmt.Print(m.DiffProvider(number - 1))
+			continue
+		}
+		for _, token := range strings.Split(line, ",") {
+			token = strings.TrimSpace(token)
+			index := slices.Index(m.Options, token)
+			if number, err := strconv.Atoi(token); err == nil {
+				index = number - 1
+			}
+			if index < 0 || index >= len(m.Options) {
+				fmt.Printf("%sInvalid entry %q%s\n", utils.ColorRed, token, utils.Reset)
+				continue
+			}
+			m.Selected[index] = !m.Selected[index]
+		}
+	}
+}
+
+func runSelectLinePrompt(m SelectModel) (SelectModel, error) {
+	fmt.Printf("%s\n", m.HeaderText)
+	for i, option := range m.Options {
+		fmt.Printf("%3d %s\n", i+1, option)
+	}
+	for {
+		fmt.Print("Enter number or name ('q' to quit): ")
+		line, err := readLine()
+		if err != nil {
+			return m, err
+		}
+		if line == "q" {
+			m.ShouldExit = true
+			return m, nil
+		}
+		index := slices.Index(m.Options, line)
+		if number, err := strconv.Atoi(line); err == nil {
+			index = number - 1
+		}
+		if index >= 0 && index < len(m.Options) {
+			m.Cursor = index
+			return m, nil
+		}
+		fmt.Printf("%sInvalid Input%s\n", utils.ColorRed, utils.Reset)
+	}
+}
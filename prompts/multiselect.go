@@ -8,10 +8,21 @@ import (
 )
 
 type MultiSelectModel struct {
-	HeaderText        string
-	Options           []string
-	Cursor            int
-	Selected          []bool
+	HeaderText string
+	Options    []string
+	Cursor     int
+	Selected   []bool
+	// Optional display labels, options are displayed as they are otherwise
+	Labels []string
+	// Optional group of each option, options of the same group are expected to be adjacent
+	Groups []string
+	// Optional details displayed next to each option
+	Annotations []string
+	// Optional provider of detailed comparison of option under cursor, toggled with 'd'
+	DiffProvider      func(index int) string
+	DiffText          string
+	Present           []bool
+	ShowSyncMarkers   bool
 	ShouldDisplayHelp bool
 	ShouldExit        bool
 }
@@ -19,7 +30,9 @@ type MultiSelectModel struct {
 const MultiSelectHelpText = "Press\n" +
 	"* 'arrow-up'/'arrow-down'/'j'/'k' for Navigation\n" +
 	"* 'space' for selection\n" +
-	"* 'h'/'t' to toggle visiblity of help\n\n"
+	"* 'h'/'t' to toggle visiblity of help\n"
+
+const MultiSelectDiffHelpText = "* 'd' to show differences of entry under cursor\n"
 
 func CreateMultiSelectModel(headerText string, options []string, preselections []bool) MultiSelectModel {
 	return MultiSelectModel{
@@ -30,6 +43,14 @@ func CreateMultiSelectModel(headerText string, options []string, preselections [
 	}
 }
 
+// Entries present in target directories are marked by what happens to them on submit
+func CreateSyncMultiSelectModel(headerText string, options []string, preselections []bool, present []bool) MultiSelectModel {
+	model := CreateMultiSelectModel(headerText, options, preselections)
+	model.Present = present
+	model.ShowSyncMarkers = true
+	return model
+}
+
 func (m MultiSelectModel) Init() tea.Cmd {
 	return nil
 }
@@ -37,9 +58,23 @@ func (m MultiSelectModel) Init() tea.Cmd {
 func (m MultiSelectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.DiffText != "" {
+			switch msg.String() {
+			case "d", "q", "esc":
+				m.DiffText = ""
+			case "ctrl+c":
+				m.ShouldExit = true
+				return m, tea.Quit
+			}
+			return m, nil
+		}
 		switch msg.String() {
 		case "enter":
 			return m, tea.Quit
+		case "d":
+			if m.DiffProvider != nil {
+				m.DiffText = m.DiffProvider(m.Cursor)
+			}
 		case "up", "k":
 			if m.Cursor > 0 {
 				m.Cursor--
@@ -67,11 +102,21 @@ func (m MultiSelectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m MultiSelectModel) View() string {
+	if m.DiffText != "" {
+		return fmt.Sprintf("%s\nPress d/q/esc to return to selection.\n", m.DiffText)
+	}
 	s := fmt.Sprintf("%s\n", m.HeaderText)
 	if m.ShouldDisplayHelp {
 		s += MultiSelectHelpText
+		if m.DiffProvider != nil {
+			s += MultiSelectDiffHelpText
+		}
+		s += "\n"
 	}
-	for i, option := range m.Options {
+	for i := range m.Options {
+		if groupHeader, ok := m.groupHeader(i); ok {
+			s += fmt.Sprintf("  %s%s%s\n", utils.FontBold, groupHeader, utils.Reset)
+		}
 		cursor := " "
 		if m.Cursor == i {
 			cursor = ">"
@@ -81,8 +126,51 @@ func (m MultiSelectModel) View() string {
 		if val = m.Selected[i]; val {
 			checked = fmt.Sprintf("%s[x]%s", utils.ColorGreen, utils.Reset)
 		}
-		s += fmt.Sprintf("%s %s %s\n", cursor, checked, option)
+		s += fmt.Sprintf("%s %s %s%s%s\n", cursor, checked, m.optionLabel(i), m.annotation(i), m.syncMarker(i))
 	}
 	s += "\nPress ENTER to submit, q/esc/ctrl+c to quit.\n"
 	return s
 }
+
+func (m MultiSelectModel) syncMarker(i int) string {
+	if !m.ShowSyncMarkers {
+		return ""
+	}
+	switch {
+	case m.Selected[i] && m.Present[i]:
+		return fmt.Sprintf(" [%sKEEP%s]", utils.ColorYellow, utils.Reset)
+	case m.Selected[i]:
+		return fmt.Sprintf(" [%sADD%s]", utils.ColorBlue, utils.Reset)
+	case m.Present[i]:
+		return fmt.Sprintf(" [%sREMOVE%s]", utils.ColorRed, utils.Reset)
+	}
+	return ""
+}
+
+func (m MultiSelectModel) groupHeader(i int) (string, bool) {
+	if len(m.Groups) == 0 || m.Groups[i] == "" {
+		return "", false
+	}
+	if i > 0 && m.Groups[i-1] == m.Groups[i] {
+		return "", false
+	}
+	return m.Groups[i] + "/", true
+}
+
+func (m MultiSelectModel) optionLabel(i int) string {
+	label := m.Options[i]
+	if len(m.Labels) > 0 {
+		label = m.Labels[i]
+	}
+	if len(m.Groups) > 0 && m.Groups[i] != "" {
+		return "  " + label
+	}
+	return label
+}
+
+func (m MultiSelectModel) annotation(i int) string {
+	if len(m.Annotations) == 0 || m.Annotations[i] == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", m.Annotations[i])
+}
@@ -0,0 +1,73 @@
+package prompts
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/koniferous22/dot-user-git-util/utils"
+)
+
+type SelectModel struct {
+	HeaderText        string
+	Options           []string
+	Cursor            int
+	ShouldDisplayHelp bool
+	ShouldExit        bool
+}
+
+const SelectHelpText = "Press\n" +
+	"* 'arrow-up'/'arrow-down'/'j'/'k' for Navigation\n" +
+	"* 'h'/'t' to toggle visiblity of help\n\n"
+
+func CreateSelectModel(headerText string, options []string) SelectModel {
+	return SelectModel{
+		HeaderText:        headerText,
+		Options:           options,
+		ShouldDisplayHelp: true,
+	}
+}
+
+func (m SelectModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m SelectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			return m, tea.Quit
+		case "up", "k":
+			if m.Cursor > 0 {
+				m.Cursor--
+			}
+		case "down", "j":
+			if m.Cursor < len(m.Options)-1 {
+				m.Cursor++
+			}
+		case "h", "t":
+			m.ShouldDisplayHelp = !m.ShouldDisplayHelp
+			return m, nil
+		case "q", "esc", "ctrl+c":
+			m.ShouldExit = true
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m SelectModel) View() string {
+	s := fmt.Sprintf("%s\n", m.HeaderText)
+	if m.ShouldDisplayHelp {
+		s += SelectHelpText
+	}
+	for i, option := range m.Options {
+		if m.Cursor == i {
+			s += fmt.Sprintf("> %s%s%s\n", utils.ColorGreen, option, utils.Reset)
+		} else {
+			s += fmt.Sprintf("  %s\n", option)
+		}
+	}
+	s += "\nPress ENTER to submit, q/esc/ctrl+c to quit.\n"
+	return s
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/koniferous22/dot-user-git-util/prompts"
+	"github.com/koniferous22/dot-user-git-util/utils"
+)
+
+type RepositoryRemoval struct {
+	GitRepository string
+	TargetPresent bool
+	// Ignore targets containing managed block, except for global excludes file shared by all repositories
+	IgnoreTargets   []string
+	InstallManifest InstallManifest
+	// Resolved from ".git", which might point elsewhere in case of worktrees and submodules
+	GitDirectory string
+	// Relative paths in target directory
+	Files []string
+	// Files modified locally or differing from template entry without install record, removed only when confirmed separately
+	ModifiedFiles []string
+	// Files not installed by the util, or kept modified files, target directory is kept when any are left
+	RemainingFiles []string
+}
+
+// Compared with template entry when it still exists, otherwise with hash recorded on install
+func isRemovedFileModified(config Config, processingContext ProcessingContext, gitRepository string, installManifest InstallManifest, templateData RepositoryTemplateData, targetName string) (bool, error) {
+	if i := slices.Index(processingContext.TemplateDirectoryTargetNames, targetName); i >= 0 {
+		status, err := resolveTemplateEntryStatus(config, processingContext, i, gitRepository, templateData, installManifest)
+		return status.IsConflicting(), err
+	}
+	contents, err := os.ReadFile(filepath.Join(gitRepository, config.TargetFolder, targetName))
+	if err != nil {
+		return false, err
+	}
+	return utils.HashContents(contents) != installManifest.Files[targetName].Hash, nil
+}
+
+func resolveRepositoryRemoval(config Config, processingContext ProcessingContext, gitRepository string) (*RepositoryRemoval, error) {
+	targetPath := filepath.Join(gitRepository, config.TargetFolder)
+	targetPresent, err := checkTargetDirectoryPresent(gitRepository, config.TargetFolder)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving target directory presence\n%w", err)
+	}
+	ignoreTargets, err := getManagedIgnoreTargets(gitRepository, config.TargetFolder)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving target directory presence in .gitignore\n%w", err)
+	}
+	removal := &RepositoryRemoval{GitRepository: gitRepository, TargetPresent: targetPresent}
+	for _, ignoreTarget := range ignoreTargets {
+		if ignoreTarget != IgnoreTargetGlobal {
+			removal.IgnoreTargets = append(removal.IgnoreTargets, ignoreTarget)
+		}
+	}
+	if !targetPresent {
+		return removal, nil
+	}
+	installManifest, err := LoadInstallManifest(gitRepository, config.TargetFolder)
+	if err != nil {
+		return nil, err
+	}
+	removal.InstallManifest = *installManifest
+	removal.GitDirectory, err = resolveGitDirectory(gitRepository)
+	if err != nil {
+		return nil, err
+	}
+	for targetName := range installManifest.Files {
+		if _, err := os.Stat(filepath.Join(targetPath, targetName)); err == nil {
+			removal.Files = append(removal.Files, targetName)
+		}
+	}
+	if !config.FlagRemoveManifestOnly {
+		for _, targetName := range processingContext.TemplateDirectoryTargetNames {
+			if slices.Contains(removal.Files, targetName) {
+				continue
+			}
+			occurrences, err := CheckEntryInTargetDirectories([]string{gitRepository}, config.TargetFolder, targetName, false)
+			if err != nil {
+				return nil, err
+			}
+			if (*occurrences)[0] {
+				removal.Files = append(removal.Files, targetName)
+			}
+		}
+	}
+	slices.Sort(removal.Files)
+	templateData := resolveRepositoryTemplateData(config, processingContext, gitRepository)
+	var unmodifiedFiles []string
+	for _, targetName := range removal.Files {
+		isModified, err := isRemovedFileModified(config, processingContext, gitRepository, *installManifest, templateData, targetName)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving status of %q:\n%w", targetName, err)
+		}
+		if isModified {
+			removal.ModifiedFiles = append(removal.ModifiedFiles, targetName)
+		} else {
+			unmodifiedFiles = append(unmodifiedFiles, targetName)
+		}
+	}
+	removal.Files = unmodifiedFiles
+	contents, err := utils.ListDirectoryContents(targetPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, targetDirectoryEntry := range contents {
+		if os.IsPathSeparator(targetDirectoryEntry[len(targetDirectoryEntry)-1]) || targetDirectoryEntry == InstallManifestFileName || slices.Contains(removal.Files, targetDirectoryEntry) || slices.Contains(removal.ModifiedFiles, targetDirectoryEntry) {
+			continue
+		}
+		removal.RemainingFiles = append(removal.RemainingFiles, targetDirectoryEntry)
+	}
+	return removal, nil
+}
+
+func runRemovalPrompt(config Config, processingContext ProcessingContext, removals []RepositoryRemoval) (*prompts.YesNoModel, error) {
+	promptMessage := "-----------------------------------------------------\n" +
+		"Do you want to remove target from following repositories\n"
+	for _, removal := range removals {
+		promptMessage += fmt.Sprintf("* %s%q%s [%sREMOVE%s]\n", utils.FontBold, removal.GitRepository, utils.Reset, utils.ColorRed, utils.Reset)
+		for _, targetName := range removal.Files {
+			promptMessage += fmt.Sprintf("    %s- %s%s\n", utils.ColorRed, config.TargetFolder+string(filepath.Separator)+targetName, utils.Reset)
+		}
+		for _, targetName := range removal.ModifiedFiles {
+			promptMessage += fmt.Sprintf("    %s! %s (differs from template, confirmed separately)%s\n", utils.ColorPurple, config.TargetFolder+string(filepath.Separator)+targetName, utils.Reset)
+		}
+		for _, ignoreTarget := range removal.IgnoreTargets {
+			ignoreFilePath, err := GetIgnoreFilePath(removal.GitRepository, ignoreTarget)
+			if err != nil {
+				return nil, err
+			}
+			promptMessage += fmt.Sprintf("    %s- %s: %s%s\n", utils.ColorRed, ignoreFilePath, GetGitignorePattern(config.TargetFolder), utils.Reset)
+		}
+		for _, targetDirectoryEntry := range removal.RemainingFiles {
+			promptMessage += fmt.Sprintf("    %s~ %s (kept)%s\n", utils.ColorYellow, config.TargetFolder+string(filepath.Separator)+targetDirectoryEntry, utils.Reset)
+		}
+	}
+	removalPromptModel := prompts.CreateYesNoModel(promptMessage, true)
+	result, err := runPrompt(processingContext, removalPromptModel)
+	if err != nil {
+		return nil, fmt.Errorf("error during removal prompt:\n%w", err)
+	}
+	if result, ok := result.(prompts.YesNoModel); ok {
+		return &result, nil
+	}
+	return nil, fmt.Errorf("error retrieving prompt results:\n%w", err)
+}
+
+func runModifiedFilesRemovalPrompt(config Config, processingContext ProcessingContext, removals []RepositoryRemoval) (*prompts.YesNoModel, error) {
+	promptMessage := "Following files differ from templates, do you want to remove them as well\n"
+	for _, removal := range removals {
+		for _, targetName := range removal.ModifiedFiles {
+			promptMessage += fmt.Sprintf("* %s%s%s\n", utils.ColorPurple, filepath.Join(removal.GitRepository, config.TargetFolder, targetName), utils.Reset)
+		}
+	}
+	modifiedFilesRemovalPromptModel := prompts.CreateYesNoModel(promptMessage, false)
+	result, err := runPrompt(processingContext, modifiedFilesRemovalPromptModel)
+	if err != nil {
+		return nil, fmt.Errorf("error during removal prompt:\n%w", err)
+	}
+	if result, ok := result.(prompts.YesNoModel); ok {
+		return &result, nil
+	}
+	return nil, fmt.Errorf("error retrieving prompt results:\n%w", err)
+}
+
+// Modified files are either removed with the rest, or kept in target directory
+func resolveModifiedFilesRemoval(removal RepositoryRemoval, isConfirmed bool) RepositoryRemoval {
+	if isConfirmed {
+		removal.Files = append(removal.Files, removal.ModifiedFiles...)
+	} else {
+		removal.RemainingFiles = append(removal.RemainingFiles, removal.ModifiedFiles...)
+	}
+	removal.ModifiedFiles = nil
+	return removal
+}
+
+func printRemovalPlan(config Config, removal RepositoryRemoval) error {
+	targetPath := filepath.Join(removal.GitRepository, config.TargetFolder)
+	plan := fmt.Sprintf("%sPlan for %q%s\n", utils.FontBold, removal.GitRepository, utils.Reset)
+	for _, targetName := range removal.Files {
+		plan += formatPlanOperation(utils.ColorRed, "DELETE", filepath.Join(targetPath, targetName))
+	}
+	for _, targetName := range removal.ModifiedFiles {
+		plan += formatPlanOperation(utils.ColorPurple, "CONFLICT", fmt.Sprintf("%s differs from template, deleted only when confirmed", filepath.Join(targetPath, targetName)))
+	}
+	if removal.InstallManifest.Exists() {
+		plan += formatPlanOperation(utils.ColorRed, "DELETE", GetInstallManifestPath(removal.GitRepository, config.TargetFolder))
+		plan += formatPlanOperation(utils.ColorRed, "DELETE", filepath.Join(removal.GitDirectory, InstallSnapshotDirectoryName, config.TargetFolder))
+	}
+	if removal.TargetPresent && len(removal.RemainingFiles) == 0 && len(removal.ModifiedFiles) == 0 {
+		plan += formatPlanOperation(utils.ColorRed, "RMDIR", targetPath)
+	}
+	for _, ignoreTarget := range removal.IgnoreTargets {
+		ignoreFilePath, err := GetIgnoreFilePath(removal.GitRepository, ignoreTarget)
+		if err != nil {
+			return err
+		}
+		plan += formatPlanOperation(utils.ColorPurple, "UNIGNORE", fmt.Sprintf("%q from %s", GetGitignorePattern(config.TargetFolder), ignoreFilePath))
+	}
+	fmt.Print(plan)
+	return nil
+}
+
+func processRemoval(config Config, removal RepositoryRemoval) error {
+	targetPath := filepath.Join(removal.GitRepository, config.TargetFolder)
+	for _, targetName := range removal.Files {
+		destinationFile := filepath.Join(targetPath, targetName)
+		if err := os.Remove(destinationFile); err != nil {
+			return fmt.Errorf("error removing %q:\n%w", destinationFile, err)
+		}
+		if err := utils.RemoveEmptyParentDirectories(destinationFile, targetPath); err != nil {
+			return err
+		}
+	}
+	if removal.InstallManifest.Exists() {
+		if err := os.Remove(GetInstallManifestPath(removal.GitRepository, config.TargetFolder)); err != nil {
+			return fmt.Errorf("error removing install manifest:\n%w", err)
+		}
+		snapshotDirectory := filepath.Join(removal.GitDirectory, InstallSnapshotDirectoryName, config.TargetFolder)
+		if err := os.RemoveAll(snapshotDirectory); err != nil {
+			return fmt.Errorf("error removing install snapshots:\n%w", err)
+		}
+		if err := utils.RemoveEmptyParentDirectories(snapshotDirectory, removal.GitDirectory); err != nil {
+			return err
+		}
+	}
+	if removal.TargetPresent {
+		if len(removal.RemainingFiles) == 0 {
+			if err := os.RemoveAll(targetPath); err != nil {
+				return fmt.Errorf("error removing target directory %q:\n%w", targetPath, err)
+			}
+		} else {
+			fmt.Printf("%sKeeping %q - contains %d files not removed%s\n", utils.ColorYellow, targetPath, len(removal.RemainingFiles), utils.Reset)
+		}
+	}
+	for _, ignoreTarget := range removal.IgnoreTargets {
+		if _, err := IgnoreTargetRemovePattern(removal.GitRepository, ignoreTarget, config.TargetFolder); err != nil {
+			return fmt.Errorf("error removing target directory from %s\n%w", IgnoreTargetDescriptions[ignoreTarget], err)
+		}
+	}
+	return nil
+}
+
+func runRemoveCommand(appConfig AppConfig) error {
+	processingContext, err := initializeCommand(&appConfig)
+	if err != nil {
+		return err
+	}
+	config := appConfig.Config
+	if !config.FlagYesInitialPrompt && !config.FlagDryRun && !isTerminalAvailable() {
+		if config.NoTtyMode != NoTtyModeLine {
+			return fmt.Errorf("stdin is not a terminal, confirm removal with \"--yes\" or use \"--no-tty=%s\"", NoTtyModeLine)
+		}
+		processingContext.LinePrompts = true
+	}
+	var removals []RepositoryRemoval
+	for _, gitRepository := range appConfig.Input.GitRepositories {
+		removal, err := resolveRepositoryRemoval(config, *processingContext, gitRepository)
+		if err != nil {
+			return err
+		}
+		if !removal.TargetPresent && len(removal.IgnoreTargets) == 0 {
+			fmt.Printf("%sSkipping repository %q - nothing to remove%s\n", utils.ColorGreen, gitRepository, utils.Reset)
+			continue
+		}
+		removals = append(removals, *removal)
+	}
+	if len(removals) == 0 {
+		return nil
+	}
+	if config.FlagDryRun {
+		for _, removal := range removals {
+			if err := printRemovalPlan(config, removal); err != nil {
+				return fmt.Errorf("error printing plan:\n%w", err)
+			}
+		}
+		return nil
+	}
+	if !config.FlagYesInitialPrompt {
+		removalPromptOutput, err := runRemovalPrompt(config, *processingContext, removals)
+		if err != nil {
+			return fmt.Errorf("encountered prompt error:\n%w", err)
+		}
+		if removalPromptOutput.ShouldExit || !removalPromptOutput.Result {
+			return errQuit
+		}
+	}
+	// Under "--yes" modified files are kept, as they'd be lost otherwise
+	isModifiedFilesRemovalConfirmed := false
+	if slices.ContainsFunc(removals, func(removal RepositoryRemoval) bool { return len(removal.ModifiedFiles) > 0 }) {
+		if config.FlagYesInitialPrompt {
+			fmt.Fprintf(os.Stderr, "%sKeeping files that differ from templates, run without \"--yes\" to confirm their removal%s\n", utils.ColorYellow, utils.Reset)
+		} else {
+			modifiedFilesRemovalPromptOutput, err := runModifiedFilesRemovalPrompt(config, *processingContext, removals)
+			if err != nil {
+				return fmt.Errorf("encountered prompt error:\n%w", err)
+			}
+			if modifiedFilesRemovalPromptOutput.ShouldExit {
+				return errQuit
+			}
+			isModifiedFilesRemovalConfirmed = modifiedFilesRemovalPromptOutput.Result
+		}
+	}
+	for _, removal := range removals {
+		removal = resolveModifiedFilesRemoval(removal, isModifiedFilesRemovalConfirmed)
+		if err := processRemoval(config, removal); err != nil {
+			return fmt.Errorf("error removing target from %q\n%w", removal.GitRepository, err)
+		}
+	}
+	return nil
+}
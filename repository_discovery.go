@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/koniferous22/dot-user-git-util/utils"
+)
+
+// Separates exclude globs of "--scan-exclude"
+const ScanExcludeSeparator = ","
+
+// Same as in git, colon-separated directories which aren't entered when looking for repository root
+const GitCeilingDirectoriesEnvVariable = "GIT_CEILING_DIRECTORIES"
+
+type repositoryScan struct {
+	root           string
+	maxDepth       int
+	excludes       []string
+	followSymlinks bool
+	// Real paths of visited directories, prevents symlink cycles
+	visited      map[string]bool
+	repositories []string
+}
+
+func parseScanExcludes(scanExclude string) ([]string, error) {
+	var excludes []string
+	for _, exclude := range strings.Split(scanExclude, ScanExcludeSeparator) {
+		exclude = strings.TrimSpace(exclude)
+		if exclude == "" {
+			continue
+		}
+		if _, err := filepath.Match(exclude, ""); err != nil {
+			return nil, fmt.Errorf("invalid scan exclude glob %q", exclude)
+		}
+		excludes = append(excludes, exclude)
+	}
+	return excludes, nil
+}
+
+// Globs are matched against directory name and path relative to scan root
+func (scan repositoryScan) isExcluded(directoryPath string) bool {
+	relativePath, err := filepath.Rel(scan.root, directoryPath)
+	if err != nil {
+		return false
+	}
+	for _, exclude := range scan.excludes {
+		if matched, _ := filepath.Match(exclude, filepath.Base(directoryPath)); matched {
+			return true
+		}
+		if matched, _ := filepath.Match(exclude, relativePath); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Repository is recognized by ".git" directory, or ".git" file in case of worktrees and submodules
+func isGitRepositoryRoot(directoryPath string) bool {
+	_, err := os.Stat(filepath.Join(directoryPath, DotGitDirectory))
+	return err == nil
+}
+
+func (scan *repositoryScan) walk(directoryPath string, depth int) {
+	realPath, err := filepath.EvalSymlinks(directoryPath)
+	if err != nil || scan.visited[realPath] {
+		return
+	}
+	scan.visited[realPath] = true
+	if isGitRepositoryRoot(directoryPath) {
+		scan.repositories = append(scan.repositories, directoryPath)
+	}
+	if scan.maxDepth >= 0 && depth >= scan.maxDepth {
+		return
+	}
+	entries, err := os.ReadDir(directoryPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%sSkipping %q during scan - %s%s\n", utils.ColorYellow, directoryPath, err.Error(), utils.Reset)
+		return
+	}
+	for _, entry := range entries {
+		entryPath := filepath.Join(directoryPath, entry.Name())
+		if entry.Name() == DotGitDirectory || scan.isExcluded(entryPath) {
+			continue
+		}
+		if entry.Type()&os.ModeSymlink != 0 {
+			if !scan.followSymlinks {
+				continue
+			}
+			if ok, _ := utils.ValidateDirectoryExists(entryPath); !ok {
+				continue
+			}
+		} else if !entry.IsDir() {
+			continue
+		}
+		scan.walk(entryPath, depth+1)
+	}
+}
+
+// Finds git repositories under root, including nested ones (e.g. submodules)
+func ScanGitRepositories(config Config) ([]string, error) {
+	root, err := filepath.Abs(config.ScanRoot)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving absolute path of scan root %q", config.ScanRoot)
+	}
+	if ok, err := utils.ValidateDirectoryExists(root); !ok {
+		if err != nil {
+			return nil, fmt.Errorf("unable to stat scan root %q\n%w", root, err)
+		}
+		return nil, fmt.Errorf("scan root %q is not a directory", root)
+	}
+	excludes, err := parseScanExcludes(config.ScanExclude)
+	if err != nil {
+		return nil, err
+	}
+	scan := repositoryScan{
+		root:           root,
+		maxDepth:       config.ScanMaxDepth,
+		excludes:       excludes,
+		followSymlinks: config.FlagScanFollowSymlinks,
+		visited:        make(map[string]bool),
+	}
+	scan.walk(root, 0)
+	if len(scan.repositories) == 0 {
+		return nil, fmt.Errorf("no git repositories found under %q", root)
+	}
+	return scan.repositories, nil
+}
+
+func getGitCeilingDirectories() []string {
+	var ceilingDirectories []string
+	for _, ceilingDirectory := range filepath.SplitList(os.Getenv(GitCeilingDirectoriesEnvVariable)) {
+		if filepath.IsAbs(ceilingDirectory) {
+			ceilingDirectories = append(ceilingDirectories, filepath.Clean(ceilingDirectory))
+		}
+	}
+	return ceilingDirectories
+}
+
+// Walks up from directory until git repository root is found, empty when there's none
+func findRepositoryRoot(directoryPath string, ceilingDirectories []string) string {
+	for {
+		if isGitRepositoryRoot(directoryPath) {
+			return directoryPath
+		}
+		parentPath := filepath.Dir(directoryPath)
+		if parentPath == directoryPath || slices.Contains(ceilingDirectories, parentPath) {
+			return ""
+		}
+		directoryPath = parentPath
+	}
+}
+
+// Arguments nested in repositories are replaced by repository root, arguments outside of repositories are kept for validation
+func resolveRepositoryRoots(gitRepositories []string) ([]string, map[string][]string) {
+	ceilingDirectories := getGitCeilingDirectories()
+	var result []string
+	resolvedArguments := make(map[string][]string)
+	for _, gitRepository := range gitRepositories {
+		repositoryRoot := findRepositoryRoot(gitRepository, ceilingDirectories)
+		if repositoryRoot == "" {
+			repositoryRoot = gitRepository
+		} else if repositoryRoot != gitRepository {
+			resolvedArguments[repositoryRoot] = append(resolvedArguments[repositoryRoot], gitRepository)
+		}
+		if !slices.Contains(result, repositoryRoot) {
+			result = append(result, repositoryRoot)
+		}
+	}
+	return result, resolvedArguments
+}
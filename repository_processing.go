@@ -1,23 +1,51 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
-	tea "github.com/charmbracelet/bubbletea"
 	"github.com/koniferous22/dot-user-git-util/prompts"
 	"github.com/koniferous22/dot-user-git-util/utils"
 )
 
+// Separates repository globs of "--gitignore-repos"
+const GitignoreRepositoriesSeparator = ","
+
 type ProcessingContext struct {
+	TemplateProfiles []TemplateProfile
+	// Relative paths in profile template directories
 	TemplateDirectoryContents []string
+	// Profile names, corresponding to "TemplateDirectoryContents"
+	TemplateDirectoryProfiles []string
+	// Relative paths in target directory, corresponding to "TemplateDirectoryContents"
+	TemplateDirectoryTargetNames []string
+	TemplateRules                []TemplateRule
+	// Visibilities corresponding to "TemplateDirectoryContents", nil when no template visibility file is found
+	TemplateDirectoryVisibilities []string
+	LinePrompts                   bool
+	// Arguments nested in repositories, keyed by resolved repository root
+	ResolvedRepositoryArguments map[string][]string
 }
 
 type RepositoryFragmentContext struct {
-	InputGitRepositories           []string
-	TargetDirectoryPresence        []bool
-	GitignorePresence              []bool
+	InputGitRepositories    []string
+	TargetDirectoryPresence []bool
+	GitignorePresence       []bool
+	// Pattern that made target directory ignored, formatted as "<source>:<line>:<pattern>"
+	// With template visibilities, ignore file containing patterns of private entries instead
+	GitignoreSources               []string
 	TemplateDirectoryPreselections []bool
+	// Indexed by template directory entry, then by repository
+	TemplateDirectoryOccurrences [][]bool
+	// Suggested by repository markers from template rules, indexed the same way as occurrences
+	TemplateDirectoryDetections [][]bool
+	// Content comparison with repository copies, indexed the same way as occurrences
+	TemplateDirectoryStatuses [][]TemplateEntryStatus
+	InstallManifests          []InstallManifest
 }
 
 type InitializationResult struct {
@@ -29,16 +57,38 @@ func runInitialPrompt(config Config, processingContext ProcessingContext, reposi
 		"Do you want to initialize/update following repositories\n"
 	for i, gitRepository := range repositoryFragmentContext.InputGitRepositories {
 		var targetDirectoryOperation string
-		if repositoryFragmentContext.TargetDirectoryPresence[i] {
+		var targetDirectoryContents []string
+		if repositoryFragmentContext.TargetDirectoryPresence[i] && config.FlagForceReinitialize {
+			targetDirectoryOperation = fmt.Sprintf("[%sREINIT%s]", utils.ColorRed, utils.Reset)
+			contents, err := utils.ListDirectoryContents(filepath.Join(gitRepository, config.TargetFolder))
+			if err != nil {
+				return nil, fmt.Errorf("error listing contents of target directory in %q:\n%w", gitRepository, err)
+			}
+			targetDirectoryContents = contents
+		} else if repositoryFragmentContext.TargetDirectoryPresence[i] {
 			targetDirectoryOperation = fmt.Sprintf("[%sUPDATE%s]", utils.ColorYellow, utils.Reset)
+			var repositoryStatuses []TemplateEntryStatus
+			for _, entryStatuses := range repositoryFragmentContext.TemplateDirectoryStatuses {
+				if entryStatuses[i] != TemplateEntryStatusNew {
+					repositoryStatuses = append(repositoryStatuses, entryStatuses[i])
+				}
+			}
+			if len(repositoryStatuses) > 0 {
+				targetDirectoryOperation += " " + formatTemplateEntryStatuses(repositoryStatuses)
+			}
 		} else {
 			targetDirectoryOperation = fmt.Sprintf("[%sCREATE%s]", utils.ColorBlue, utils.Reset)
 		}
 		promptMessage += fmt.Sprintf("* %s%q%s %s\n", utils.FontBold, gitRepository, utils.Reset, targetDirectoryOperation)
+		for _, resolvedArgument := range processingContext.ResolvedRepositoryArguments[gitRepository] {
+			promptMessage += fmt.Sprintf("    %sresolved from %q%s\n", utils.ColorCyan, resolvedArgument, utils.Reset)
+		}
+		for _, targetDirectoryEntry := range targetDirectoryContents {
+			promptMessage += fmt.Sprintf("    %s- %s%s\n", utils.ColorRed, config.TargetFolder+string(filepath.Separator)+targetDirectoryEntry, utils.Reset)
+		}
 	}
 	initialPromptModel := prompts.CreateYesNoModel(promptMessage, !config.FlagPerRepoMode)
-	program := tea.NewProgram(initialPromptModel)
-	result, err := program.Run()
+	result, err := runPrompt(processingContext, initialPromptModel)
 	if err != nil {
 		return nil, fmt.Errorf("error during initial prompt:\n%w", err)
 	}
@@ -54,9 +104,38 @@ func runSelectionPrompt(config Config, processingContext ProcessingContext, repo
 	for _, gitRepository := range repositoryFragmentContext.InputGitRepositories {
 		promptMessage += fmt.Sprintf("* %s%q%s\n", utils.FontBold, gitRepository, utils.Reset)
 	}
-	selectionPromptModel := prompts.CreateMultiSelectModel(promptMessage, processingContext.TemplateDirectoryContents, repositoryFragmentContext.TemplateDirectoryPreselections)
-	program := tea.NewProgram(selectionPromptModel)
-	result, err := program.Run()
+	var selectionPromptModel prompts.MultiSelectModel
+	if config.FlagSyncMode {
+		present := make([]bool, len(processingContext.TemplateDirectoryContents))
+		for i, entryOccurences := range repositoryFragmentContext.TemplateDirectoryOccurrences {
+			present[i] = utils.ValidateAtLeastOneTrue(entryOccurences)
+		}
+		selectionPromptModel = prompts.CreateSyncMultiSelectModel(promptMessage, processingContext.GetTemplateEntryIdentifiers(), repositoryFragmentContext.TemplateDirectoryPreselections, present)
+	} else {
+		selectionPromptModel = prompts.CreateMultiSelectModel(promptMessage, processingContext.GetTemplateEntryIdentifiers(), repositoryFragmentContext.TemplateDirectoryPreselections)
+	}
+	selectionPromptModel.Annotations = make([]string, len(processingContext.TemplateDirectoryContents))
+	for i, entryStatuses := range repositoryFragmentContext.TemplateDirectoryStatuses {
+		selectionPromptModel.Annotations[i] = formatTemplateEntryStatuses(entryStatuses)
+		if processingContext.HasTemplateVisibilities() {
+			selectionPromptModel.Annotations[i] = formatTemplateVisibility(processingContext.TemplateDirectoryVisibilities[i]) + ", " + selectionPromptModel.Annotations[i]
+		}
+	}
+	selectionPromptModel.DiffProvider = func(i int) string {
+		diff, err := resolveTemplateEntryDiff(config, processingContext, repositoryFragmentContext, i)
+		if err != nil {
+			return fmt.Sprintf("%serror resolving differences:\n%s%s\n", utils.ColorRed, err.Error(), utils.Reset)
+		}
+		return diff
+	}
+	if config.FlagRecursiveTemplate || len(processingContext.TemplateProfiles) > 1 {
+		selectionPromptModel.Groups = resolveTemplateEntryGroups(processingContext)
+		selectionPromptModel.Labels = make([]string, len(processingContext.TemplateDirectoryContents))
+		for i, templateDirectoryEntry := range processingContext.TemplateDirectoryContents {
+			selectionPromptModel.Labels[i] = filepath.Base(templateDirectoryEntry)
+		}
+	}
+	result, err := runPrompt(processingContext, selectionPromptModel)
 	if err != nil {
 		return nil, fmt.Errorf("error during selection prompt:\n%w", err)
 	}
@@ -66,10 +145,25 @@ func runSelectionPrompt(config Config, processingContext ProcessingContext, repo
 	return nil, fmt.Errorf("error retrieving prompt results:\n%w", err)
 }
 
+// Entries are grouped by profile (when multiple are configured) and nested directory
+func resolveTemplateEntryGroups(processingContext ProcessingContext) []string {
+	groups := make([]string, len(processingContext.TemplateDirectoryContents))
+	for i, templateDirectoryEntry := range processingContext.TemplateDirectoryContents {
+		var groupSegments []string
+		if len(processingContext.TemplateProfiles) > 1 {
+			groupSegments = append(groupSegments, processingContext.TemplateDirectoryProfiles[i])
+		}
+		if directory := filepath.Dir(templateDirectoryEntry); directory != "." {
+			groupSegments = append(groupSegments, filepath.ToSlash(directory))
+		}
+		groups[i] = strings.Join(groupSegments, "/")
+	}
+	return groups
+}
+
 func runGitignorePrompt(config Config, processingContext ProcessingContext, repositoryFragmentContext RepositoryFragmentContext) (*prompts.YesNoModel, error) {
-	gitignorePromptModel := prompts.CreateYesNoModel(fmt.Sprintf("Do you want to add %q to .gitignore", config.TargetFolder), false)
-	program := tea.NewProgram(gitignorePromptModel)
-	result, err := program.Run()
+	gitignorePromptModel := prompts.CreateYesNoModel(fmt.Sprintf("Do you want to add %q to %s", config.TargetFolder, IgnoreTargetDescriptions[config.IgnoreTarget]), false)
+	result, err := runPrompt(processingContext, gitignorePromptModel)
 	if err != nil {
 		return nil, fmt.Errorf("error during gitignore prompt:\n%w", err)
 	}
@@ -79,104 +173,469 @@ func runGitignorePrompt(config Config, processingContext ProcessingContext, repo
 	return nil, fmt.Errorf("error retrieving prompt results:\n%w", err)
 }
 
-func processInitialization(templateDirectory string, templateDirectoryContents []string, gitRepositories []string, targetDirectory string, templateSelections []bool) error {
-	for _, gitRepository := range gitRepositories {
-		targetPath := filepath.Join(gitRepository, targetDirectory)
+func runGitignoreChecklistPrompt(config Config, processingContext ProcessingContext, repositoryFragmentContext RepositoryFragmentContext) (*prompts.MultiSelectModel, error) {
+	promptMessage := fmt.Sprintf("Pick repositories where %q should be ignored", config.TargetFolder)
+	gitignoreChecklistPromptModel := prompts.CreateMultiSelectModel(promptMessage, repositoryFragmentContext.InputGitRepositories, slices.Clone(repositoryFragmentContext.GitignorePresence))
+	gitignoreChecklistPromptModel.Annotations = make([]string, len(repositoryFragmentContext.InputGitRepositories))
+	for i, gitignoreSource := range repositoryFragmentContext.GitignoreSources {
+		if gitignoreSource != "" {
+			gitignoreChecklistPromptModel.Annotations[i] = fmt.Sprintf("ignored by %s", gitignoreSource)
+		}
+	}
+	result, err := runPrompt(processingContext, gitignoreChecklistPromptModel)
+	if err != nil {
+		return nil, fmt.Errorf("error during gitignore prompt:\n%w", err)
+	}
+	if result, ok := result.(prompts.MultiSelectModel); ok {
+		return &result, nil
+	}
+	return nil, fmt.Errorf("error retrieving prompt results:\n%w", err)
+}
+
+// Last option leaves target directory not ignored
+func runIgnoreTargetPrompt(config Config, processingContext ProcessingContext, repositoryFragmentContext RepositoryFragmentContext) (*prompts.SelectModel, error) {
+	var options []string
+	for _, ignoreTarget := range IgnoreTargets {
+		options = append(options, fmt.Sprintf("Add to %s", IgnoreTargetDescriptions[ignoreTarget]))
+	}
+	options = append(options, "Don't ignore")
+	ignoreTargetPromptModel := prompts.CreateSelectModel(fmt.Sprintf("Do you want to ignore %q", config.TargetFolder), options)
+	result, err := runPrompt(processingContext, ignoreTargetPromptModel)
+	if err != nil {
+		return nil, fmt.Errorf("error during gitignore prompt:\n%w", err)
+	}
+	if result, ok := result.(prompts.SelectModel); ok {
+		return &result, nil
+	}
+	return nil, fmt.Errorf("error retrieving prompt results:\n%w", err)
+}
+
+func resolveFlagSelections(config Config, processingContext ProcessingContext, repositoryFragmentContext RepositoryFragmentContext) (*[]bool, error) {
+	result := make([]bool, len(processingContext.TemplateDirectoryContents))
+	if config.FlagSelectAll {
+		for i := range result {
+			result[i] = true
+		}
+		return &result, nil
+	}
+	if config.FlagSelectPreselected {
+		copy(result, repositoryFragmentContext.TemplateDirectoryPreselections)
+		return &result, nil
+	}
+	if config.selectInstalled {
+		for i, entryOccurrenceInGitRepositories := range repositoryFragmentContext.TemplateDirectoryOccurrences {
+			result[i] = utils.ValidateAtLeastOneTrue(entryOccurrenceInGitRepositories)
+		}
+		return &result, nil
+	}
+	var unknownEntries []string
+	for _, selectedEntry := range strings.Split(config.SelectEntries, ",") {
+		selectedEntry = strings.TrimSpace(selectedEntry)
+		if selectedEntry == "" {
+			continue
+		}
+		entryIndex, err := processingContext.FindTemplateEntry(selectedEntry)
+		if err != nil {
+			return nil, err
+		}
+		if entryIndex < 0 {
+			unknownEntries = append(unknownEntries, selectedEntry)
+			continue
+		}
+		result[entryIndex] = true
+	}
+	if len(unknownEntries) > 0 {
+		return nil, fmt.Errorf(
+			"unknown template entries %q\navailable entries: %q",
+			unknownEntries,
+			processingContext.GetTemplateEntryIdentifiers(),
+		)
+	}
+	return &result, nil
+}
+
+func installTemplateEntry(config Config, processingContext ProcessingContext, repositoryFragmentContext RepositoryFragmentContext, i int, j int, templateData RepositoryTemplateData, installManifest InstallManifest) error {
+	gitRepository := repositoryFragmentContext.InputGitRepositories[i]
+	targetName := processingContext.TemplateDirectoryTargetNames[j]
+	destinationFile := filepath.Join(gitRepository, config.TargetFolder, targetName)
+	if err := utils.EnsureDirectoryExists(filepath.Dir(destinationFile)); err != nil {
+		return err
+	}
+	templateContents, err := resolveTemplateEntryContents(config, processingContext, j, templateData)
+	if err != nil {
+		return err
+	}
+	sourceInfo, err := os.Stat(processingContext.GetTemplateEntrySourceFile(j))
+	if err != nil {
+		return fmt.Errorf("error getting source file info %q:\n%w", processingContext.GetTemplateEntrySourceFile(j), err)
+	}
+	isInstalled := true
+	// Conflict markers are reported once merged contents are recorded, so that next run sees them as local modification
+	var mergeConflictsErr error
+	if status := repositoryFragmentContext.TemplateDirectoryStatuses[j][i]; !config.FlagForceReinitialize && status.IsConflicting() {
+		resolution, err := resolveConflictResolution(config, processingContext, describeConflict(destinationFile, status), ConflictResolutions)
+		if err != nil {
+			return err
+		}
+		baseContents, err := loadInstallSnapshot(gitRepository, config.TargetFolder, targetName)
+		if err != nil {
+			return err
+		}
+		isInstalled, err = applyConflictResolution(resolution, destinationFile, templateContents, baseContents, sourceInfo.Mode().Perm())
+		if errors.Is(err, errMergeConflicts) {
+			mergeConflictsErr = err
+		} else if err != nil {
+			return fmt.Errorf("error resolving conflict in %q:\n%w", destinationFile, err)
+		}
+	} else if err := utils.WriteFile(destinationFile, templateContents, sourceInfo.Mode().Perm()); err != nil {
+		return err
+	}
+	if !isInstalled {
+		return nil
+	}
+	if err := installManifest.RecordInstall(
+		gitRepository,
+		config.TargetFolder,
+		targetName,
+		processingContext.TemplateDirectoryProfiles[j],
+		processingContext.GetTemplateEntrySourceFile(j),
+		templateContents,
+		sourceInfo.Mode(),
+	); err != nil {
+		return err
+	}
+	return mergeConflictsErr
+}
+
+// Locally modified entries are removed according to conflict policy, returns whether entry was removed
+func removeDeselectedTemplateEntry(config Config, processingContext ProcessingContext, repositoryFragmentContext RepositoryFragmentContext, i int, j int) (bool, error) {
+	destinationFile := filepath.Join(repositoryFragmentContext.InputGitRepositories[i], config.TargetFolder, processingContext.TemplateDirectoryTargetNames[j])
+	if status := repositoryFragmentContext.TemplateDirectoryStatuses[j][i]; !config.FlagForceReinitialize && status.IsConflicting() {
+		resolution, err := resolveConflictResolution(config, processingContext, describeConflict(destinationFile, status)+" and was deselected", ConflictRemovalResolutions)
+		if err != nil {
+			return false, err
+		}
+		if isRemovable, err := applyConflictRemoval(resolution, destinationFile); err != nil || !isRemovable {
+			return false, err
+		}
+	}
+	if err := os.Remove(destinationFile); err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("error removing deselected file %q:\n%w", destinationFile, err)
+	}
+	return true, nil
+}
+
+// Merge conflicts don't stop processing, they're returned joined once all repositories are processed
+func processInitialization(config Config, processingContext ProcessingContext, repositoryFragmentContext RepositoryFragmentContext, templateSelections []bool) error {
+	var mergeConflicts []error
+	for i, gitRepository := range repositoryFragmentContext.InputGitRepositories {
+		targetPath := filepath.Join(gitRepository, config.TargetFolder)
 
 		err := utils.EnsureDirectoryExists(targetPath)
 		if err != nil {
 			return err
 		}
+		installManifest := repositoryFragmentContext.InstallManifests[i]
+		if config.FlagForceReinitialize {
+			if err := utils.RemoveAllFilesInDirectory(targetPath); err != nil {
+				return fmt.Errorf("error purging target directory %q:\n%w", targetPath, err)
+			}
+			installManifest.Files = make(map[string]InstalledFile)
+		}
 
+		templateData := resolveRepositoryTemplateData(config, processingContext, gitRepository)
 		for j, isSelected := range templateSelections {
+			targetName := processingContext.TemplateDirectoryTargetNames[j]
+			destinationFile := filepath.Join(targetPath, targetName)
 			if isSelected {
-				templateFile := templateDirectoryContents[j]
-				sourceFile := filepath.Join(templateDirectory, templateFile)
-				destinationFile := filepath.Join(targetPath, filepath.Base(templateFile))
-				err := utils.CopyFile(sourceFile, destinationFile)
+				err := installTemplateEntry(config, processingContext, repositoryFragmentContext, i, j, templateData, installManifest)
+				if errors.Is(err, errMergeConflicts) {
+					mergeConflicts = append(mergeConflicts, err)
+				} else if err != nil {
+					return err
+				}
+			} else if config.FlagSyncMode && repositoryFragmentContext.TemplateDirectoryOccurrences[j][i] {
+				isRemoved, err := removeDeselectedTemplateEntry(config, processingContext, repositoryFragmentContext, i, j)
 				if err != nil {
 					return err
 				}
+				if !isRemoved {
+					continue
+				}
+				if err := utils.RemoveEmptyParentDirectories(destinationFile, targetPath); err != nil {
+					return err
+				}
+				if err := installManifest.RecordRemoval(gitRepository, config.TargetFolder, targetName); err != nil {
+					return err
+				}
 			}
 		}
+		if err := installManifest.Save(gitRepository, config.TargetFolder); err != nil {
+			return fmt.Errorf("error saving install manifest in %q:\n%w", targetPath, err)
+		}
 	}
-	return nil
+	return errors.Join(mergeConflicts...)
 }
 
-func processGitignore(gitRepositories []string, targetDirectory string, gitignoreReferencesFound []bool) error {
-	gitignorePattern := GetGitignorePattern(targetDirectory)
-	for i, gitRepository := range gitRepositories {
-		if gitignoreReferencesFound[i] {
+// With template visibilities, managed blocks of already ignored repositories are refreshed, as private entries might have changed
+func processGitignore(config Config, processingContext ProcessingContext, repositoryFragmentContext RepositoryFragmentContext, gitignoreSelections []bool, ignorePatterns []string, ignoreTarget string) error {
+	// Global excludes file is shared by repositories
+	writtenIgnoreFiles := make(map[string]bool)
+	writeIgnoreFile := func(gitRepository string, ignoreTarget string) error {
+		ignoreFilePath, err := GetIgnoreFilePath(gitRepository, ignoreTarget)
+		if err != nil {
+			return fmt.Errorf("error resolving %s:\n%w", IgnoreTargetDescriptions[ignoreTarget], err)
+		}
+		if writtenIgnoreFiles[ignoreFilePath] {
+			return nil
+		}
+		patterns := ignorePatterns
+		if ignoreTarget == IgnoreTargetGlobal {
+			if patterns, err = mergeManagedBlockPatterns(ignoreFilePath, config.TargetFolder, ignorePatterns); err != nil {
+				return fmt.Errorf("error reading managed block of %q:\n%w", ignoreFilePath, err)
+			}
+		}
+		if err := writeManagedBlock(ignoreFilePath, patterns); err != nil {
+			return fmt.Errorf("error writing managed block to %q:\n%w", ignoreFilePath, err)
+		}
+		writtenIgnoreFiles[ignoreFilePath] = true
+		return nil
+	}
+	for i, gitRepository := range repositoryFragmentContext.InputGitRepositories {
+		gitignoreReferenceFound := repositoryFragmentContext.GitignorePresence[i]
+		if gitignoreReferenceFound && !gitignoreSelections[i] {
+			if err := processUnignore(gitRepository, config.TargetFolder); err != nil {
+				return err
+			}
+			continue
+		}
+		if gitignoreReferenceFound && processingContext.HasTemplateVisibilities() {
+			managedIgnoreTargets, err := getManagedIgnoreTargets(gitRepository, config.TargetFolder)
+			if err != nil {
+				return err
+			}
+			for _, managedIgnoreTarget := range managedIgnoreTargets {
+				if err := writeIgnoreFile(gitRepository, managedIgnoreTarget); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if gitignoreReferenceFound || !gitignoreSelections[i] {
 			continue
 		}
+		if err := writeIgnoreFile(gitRepository, ignoreTarget); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-		if err := GitignoreWritePattern(gitRepository, gitignorePattern); err != nil {
-			return fmt.Errorf("error appending or creating .gitignore:\n%w", err)
+// Only managed blocks written by the util are removed, global excludes file is kept as it applies to other repositories
+func processUnignore(gitRepository string, targetDirectory string) error {
+	ignoreTargets, err := getManagedBlockIgnoreTargets(gitRepository, targetDirectory)
+	if err != nil {
+		return err
+	}
+	for _, ignoreTarget := range ignoreTargets {
+		if ignoreTarget == IgnoreTargetGlobal {
+			continue
+		}
+		ignoreFilePath, err := GetIgnoreFilePath(gitRepository, ignoreTarget)
+		if err != nil {
+			return err
 		}
+		if _, err := removeManagedBlockTarget(ignoreFilePath, targetDirectory); err != nil {
+			return fmt.Errorf("error removing target directory from %s\n%w", IgnoreTargetDescriptions[ignoreTarget], err)
+		}
+	}
+	ignoreSources, err := ResolveTargetIgnoreSources([]string{gitRepository}, targetDirectory)
+	if err != nil {
+		return err
+	}
+	if ignoreSources[0] != "" {
+		fmt.Printf("%sTarget directory in %q is still ignored by %s%s\n", utils.ColorYellow, gitRepository, ignoreSources[0], utils.Reset)
 	}
 	return nil
 }
 
-// Pre-selection algorithm
-// 1. If "Force reinitialize" Flag is set, all contents will be purged an reinitialized, therefore preselection is empty
-// 2. Iterate template directory executables
-// 3. Determine pre-selection set (preselect on found targets)
-// 4. Select template directory executables that are present in ALL directories from pre-selection set
-func resolveTemplatePreselections(config Config, processingContext ProcessingContext, gitRepositories []string) (*[]bool, error) {
-	result := make([]bool, len(processingContext.TemplateDirectoryContents))
+func selectAllRepositories(gitRepositories []string) []bool {
+	result := make([]bool, len(gitRepositories))
+	for i := range result {
+		result[i] = true
+	}
+	return result
+}
+
+func parseGitignoreRepositories(gitignoreRepositories string) ([]string, error) {
+	var globs []string
+	for _, glob := range strings.Split(gitignoreRepositories, GitignoreRepositoriesSeparator) {
+		glob = strings.TrimSpace(glob)
+		if glob == "" {
+			continue
+		}
+		if _, err := filepath.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid gitignore repository glob %q", glob)
+		}
+		globs = append(globs, glob)
+	}
+	return globs, nil
+}
+
+// Globs are matched against repository path and repository directory name, invalid globs are rejected during config validation
+// Unmatched repositories are left as they are, so that ignore files of repositories that weren't named aren't rewritten
+func resolveGitignoreRepositorySelections(config Config, gitRepositories []string, gitignorePresence []bool) []bool {
+	globs, _ := parseGitignoreRepositories(config.GitignoreRepositories)
+	result := slices.Clone(gitignorePresence)
+	for i, gitRepository := range gitRepositories {
+		for _, glob := range globs {
+			matchedPath, _ := filepath.Match(glob, gitRepository)
+			matchedName, _ := filepath.Match(glob, filepath.Base(gitRepository))
+			result[i] = result[i] || matchedPath || matchedName
+		}
+	}
+	return result
+}
+
+// Resolves which template directory entries are installed in target directories of each repository, according to install manifest
+// If "Force reinitialize" Flag is set, all contents will be purged an reinitialized, therefore nothing is considered present
+func resolveTemplateOccurrences(config Config, processingContext ProcessingContext, gitRepositories []string, installManifests []InstallManifest) (*[][]bool, error) {
+	result := make([][]bool, len(processingContext.TemplateDirectoryContents))
+	for i := range result {
+		result[i] = make([]bool, len(gitRepositories))
+	}
 	if config.FlagForceReinitialize {
 		return &result, nil
 	}
-	if config.FlagUnionPreselections {
-		for i, templateDirectoryEntry := range processingContext.TemplateDirectoryContents {
-			entryOccurenceInGitRepositories, err := CheckExecutableInTargetDirectories(gitRepositories, config.TargetFolder, templateDirectoryEntry)
-			if err != nil {
-				return nil, err
+	for j, gitRepository := range gitRepositories {
+		installManifest := installManifests[j]
+		for i, templateDirectoryTargetName := range processingContext.TemplateDirectoryTargetNames {
+			if installManifest.Exists() {
+				_, result[i][j] = installManifest.Files[templateDirectoryTargetName]
+				continue
 			}
-			result[i] = utils.ValidateAtLeastOneTrue(*entryOccurenceInGitRepositories)
-		}
-	} else {
-		for i, templateDirectoryEntry := range processingContext.TemplateDirectoryContents {
-			entryOccurenceInGitRepositories, err := CheckExecutableInTargetDirectories(gitRepositories, config.TargetFolder, templateDirectoryEntry)
+			// Fallback for target directories initialized without install manifest
+			entryOccurenceInGitRepository, err := CheckEntryInTargetDirectories([]string{gitRepository}, config.TargetFolder, templateDirectoryTargetName, !config.FlagIncludeNonExecutable)
 			if err != nil {
 				return nil, err
 			}
-			result[i] = utils.ValidateAllTrue(*entryOccurenceInGitRepositories)
+			result[i][j] = (*entryOccurenceInGitRepository)[0]
 		}
 	}
 	return &result, nil
 }
 
+// Pre-selection algorithm
+// 1. If "Force reinitialize" Flag is set, all contents will be purged an reinitialized, therefore nothing is preselected (not even by template rules)
+// 2. Iterate template directory entries
+// 3. Determine pre-selection set per repository (preselect on found targets, or targets suggested by template rules)
+// 4. Select template directory entries that are in pre-selection sets of ALL directories
+// 5. In "union" or "sync" mode, select template directory entries that are in pre-selection set of AT LEAST ONE directory instead
+//   - in "sync" mode deselection means removal, therefore every present entry has to be preselected
+func resolveTemplatePreselections(config Config, templateDirectoryOccurrences [][]bool, templateDirectoryDetections [][]bool) []bool {
+	result := make([]bool, len(templateDirectoryOccurrences))
+	if config.FlagForceReinitialize {
+		return result
+	}
+	for i, entryOccurenceInGitRepositories := range templateDirectoryOccurrences {
+		entryPreselectionInGitRepositories := make([]bool, len(entryOccurenceInGitRepositories))
+		for j, entryOccurence := range entryOccurenceInGitRepositories {
+			entryPreselectionInGitRepositories[j] = entryOccurence || templateDirectoryDetections[i][j]
+		}
+		if config.FlagUnionPreselections || config.FlagSyncMode {
+			result[i] = utils.ValidateAtLeastOneTrue(entryPreselectionInGitRepositories)
+		} else {
+			result[i] = utils.ValidateAllTrue(entryPreselectionInGitRepositories)
+		}
+	}
+	return result
+}
+
 func initializeRepositorySequenceContext(config Config, processingContext ProcessingContext, gitRepositories []string) (*RepositoryFragmentContext, error) {
 	targetDirectoryPresence, err := GetTargetDirectoryPresence(gitRepositories, config.TargetFolder)
 	if err != nil {
 		return nil, fmt.Errorf("error resolving target directory presence\n%w", err)
 	}
-	gitignorePresence, err := GetGitignorePresence(gitRepositories, config.TargetFolder)
+	gitignoreSources, err := ResolveTargetIgnoreSources(gitRepositories, config.TargetFolder)
 	if err != nil {
 		return nil, fmt.Errorf("error resolving target directory presence in .gitignore\n%w", err)
 	}
-	templateDirectoryPreselections, err := resolveTemplatePreselections(config, processingContext, gitRepositories)
+	if processingContext.HasTemplateVisibilities() {
+		err = resolvePrivateEntryIgnoreSources(gitRepositories, config.TargetFolder, gitignoreSources)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving ignore patterns of private entries\n%w", err)
+		}
+	}
+	gitignorePresence := make([]bool, len(gitRepositories))
+	for i, gitignoreSource := range gitignoreSources {
+		gitignorePresence[i] = gitignoreSource != ""
+	}
+	installManifests := make([]InstallManifest, len(gitRepositories))
+	for i, gitRepository := range gitRepositories {
+		installManifest, err := LoadInstallManifest(gitRepository, config.TargetFolder)
+		if err != nil {
+			return nil, err
+		}
+		installManifests[i] = *installManifest
+	}
+	templateDirectoryOccurrences, err := resolveTemplateOccurrences(config, processingContext, gitRepositories, installManifests)
 	if err != nil {
 		return nil, fmt.Errorf("error resolving template preselections\n%w", err)
 	}
+	templateDirectoryDetections, err := resolveTemplateDetections(processingContext, gitRepositories)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving template rules\n%w", err)
+	}
+	templateDirectoryStatuses, err := resolveTemplateStatuses(config, processingContext, gitRepositories, installManifests)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving template statuses\n%w", err)
+	}
 	return &RepositoryFragmentContext{
 		InputGitRepositories:           gitRepositories,
 		TargetDirectoryPresence:        *targetDirectoryPresence,
-		GitignorePresence:              *gitignorePresence,
-		TemplateDirectoryPreselections: *templateDirectoryPreselections,
+		GitignorePresence:              gitignorePresence,
+		GitignoreSources:               gitignoreSources,
+		TemplateDirectoryPreselections: resolveTemplatePreselections(config, *templateDirectoryOccurrences, *templateDirectoryDetections),
+		TemplateDirectoryOccurrences:   *templateDirectoryOccurrences,
+		TemplateDirectoryDetections:    *templateDirectoryDetections,
+		TemplateDirectoryStatuses:      *templateDirectoryStatuses,
+		InstallManifests:               installManifests,
 	}, nil
 }
 
 func InitializeProcessingContext(config Config) (*ProcessingContext, error) {
 
-	templateDirectoryContents, err := utils.ListTopLevelExecutablesInDirectory(config.TemplateDirectory)
+	templateProfiles, err := ParseTemplateProfiles(config.TemplateDirectory)
 	if err != nil {
-		return nil, fmt.Errorf("error listing executables in template directory %q - %w", config.TemplateDirectory, err)
+		return nil, err
 	}
-	return &ProcessingContext{
-		TemplateDirectoryContents: templateDirectoryContents,
-	}, nil
+	templateProfileEntries, err := listTemplateProfileEntries(config, templateProfiles)
+	if err != nil {
+		return nil, err
+	}
+	templateDirectoryContents := make([]string, len(templateProfileEntries))
+	templateDirectoryProfiles := make([]string, len(templateProfileEntries))
+	templateDirectoryTargetNames := make([]string, len(templateProfileEntries))
+	for i, templateProfileEntry := range templateProfileEntries {
+		templateDirectoryContents[i] = templateProfileEntry.name
+		templateDirectoryProfiles[i] = templateProfileEntry.profile
+		templateDirectoryTargetNames[i] = templateProfileEntry.targetName
+	}
+	processingContext := ProcessingContext{
+		TemplateProfiles:             templateProfiles,
+		TemplateDirectoryContents:    templateDirectoryContents,
+		TemplateDirectoryProfiles:    templateDirectoryProfiles,
+		TemplateDirectoryTargetNames: templateDirectoryTargetNames,
+	}
+	processingContext.TemplateRules, err = loadTemplateRules(processingContext)
+	if err != nil {
+		return nil, err
+	}
+	processingContext.TemplateDirectoryVisibilities, err = loadTemplateVisibilities(processingContext)
+	if err != nil {
+		return nil, err
+	}
+	return &processingContext, nil
 }
 
 func RunInitializationOnRepositories(config Config, processingContext ProcessingContext, gitRepositories []string) (*InitializationResult, error) {
@@ -206,10 +665,11 @@ func RunInitializationOnRepositories(config Config, processingContext Processing
 	if config.FlagSkipWhereGitignored && targetDirectoryPresentInAllGitignores {
 		if config.FlagPerRepoMode {
 			fmt.Printf(
-				"%sSkipping repository %q - already found in .gitignore%s\n",
+				"%sSkipping repository %q - already ignored by %s%s\n",
 				utils.ColorGreen,
 				// NOTE - size of "repositories" expected to be 1 in per-repo mode
 				repositoryFragmentContext.InputGitRepositories[0],
+				repositoryFragmentContext.GitignoreSources[0],
 				utils.Reset,
 			)
 		} else {
@@ -232,19 +692,65 @@ func RunInitializationOnRepositories(config Config, processingContext Processing
 	}
 
 	// 2. Selection Prompt
-	selectionPromptOutput, err := runSelectionPrompt(config, processingContext, *repositoryFragmentContext)
-	if err != nil {
-		return nil, handlePromptError(err)
-	}
-	if selectionPromptOutput.ShouldExit {
-		return &InitializationResult{ShouldExit: true}, nil
+	var templateSelections []bool
+	if config.HasSelectionFlag() {
+		flagSelections, err := resolveFlagSelections(config, processingContext, *repositoryFragmentContext)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving selection from flags:\n%w", err)
+		}
+		templateSelections = *flagSelections
+	} else {
+		selectionPromptOutput, err := runSelectionPrompt(config, processingContext, *repositoryFragmentContext)
+		if err != nil {
+			return nil, handlePromptError(err)
+		}
+		if selectionPromptOutput.ShouldExit {
+			return &InitializationResult{ShouldExit: true}, nil
+		}
+		templateSelections = selectionPromptOutput.Selected
 	}
 
 	// 3. .gitignore Prompt
-	shouldInitializeGitignore := false
+	ignorePatterns := processingContext.GetIgnorePatterns(config.TargetFolder)
+	ignoreTarget := config.GetIgnoreTarget()
+	// Deselecting already ignored repository removes patterns written by the util
+	gitignoreSelections := slices.Clone(repositoryFragmentContext.GitignorePresence)
 	if config.FlagGitignoreInclude {
-		shouldInitializeGitignore = true
-	} else if !config.FlagGitignoreOmit && !targetDirectoryPresentInAllGitignores {
+		gitignoreSelections = selectAllRepositories(gitRepositories)
+	} else if config.GitignoreRepositories != "" {
+		gitignoreSelections = resolveGitignoreRepositorySelections(config, gitRepositories, repositoryFragmentContext.GitignorePresence)
+	} else if !config.FlagGitignoreOmit && len(gitRepositories) > 1 {
+		// Shown even when all repositories are ignored, so that any of them can be deselected
+		gitignoreChecklistPromptOutput, err := runGitignoreChecklistPrompt(config, processingContext, *repositoryFragmentContext)
+		if err != nil {
+			return nil, handlePromptError(err)
+		}
+		if gitignoreChecklistPromptOutput.ShouldExit {
+			return &InitializationResult{ShouldExit: true}, nil
+		}
+		gitignoreSelections = gitignoreChecklistPromptOutput.Selected
+		hasAddedIgnores := false
+		for i, isSelected := range gitignoreSelections {
+			hasAddedIgnores = hasAddedIgnores || (isSelected && !repositoryFragmentContext.GitignorePresence[i])
+		}
+		if hasAddedIgnores && config.IgnoreTarget == "" {
+			ignoreTargetPromptOutput, err := runIgnoreTargetPrompt(config, processingContext, *repositoryFragmentContext)
+			if err != nil {
+				return nil, handlePromptError(err)
+			}
+			if ignoreTargetPromptOutput.ShouldExit {
+				return &InitializationResult{ShouldExit: true}, nil
+			}
+			if ignoreTargetPromptOutput.Cursor < len(IgnoreTargets) {
+				ignoreTarget = IgnoreTargets[ignoreTargetPromptOutput.Cursor]
+			} else {
+				// "Don't ignore" discards only newly selected repositories
+				for i := range gitignoreSelections {
+					gitignoreSelections[i] = gitignoreSelections[i] && repositoryFragmentContext.GitignorePresence[i]
+				}
+			}
+		}
+	} else if !config.FlagGitignoreOmit && !targetDirectoryPresentInAllGitignores && config.IgnoreTarget != "" {
 		gitignorePromptOutput, err := runGitignorePrompt(config, processingContext, *repositoryFragmentContext)
 		if err != nil {
 			return nil, handlePromptError(err)
@@ -252,25 +758,49 @@ func RunInitializationOnRepositories(config Config, processingContext Processing
 		if gitignorePromptOutput.ShouldExit {
 			return &InitializationResult{ShouldExit: true}, nil
 		}
-		shouldInitializeGitignore = gitignorePromptOutput.Result
+		if gitignorePromptOutput.Result {
+			gitignoreSelections = selectAllRepositories(gitRepositories)
+		}
+	} else if !config.FlagGitignoreOmit && !targetDirectoryPresentInAllGitignores {
+		ignoreTargetPromptOutput, err := runIgnoreTargetPrompt(config, processingContext, *repositoryFragmentContext)
+		if err != nil {
+			return nil, handlePromptError(err)
+		}
+		if ignoreTargetPromptOutput.ShouldExit {
+			return &InitializationResult{ShouldExit: true}, nil
+		}
+		if ignoreTargetPromptOutput.Cursor < len(IgnoreTargets) {
+			ignoreTarget = IgnoreTargets[ignoreTargetPromptOutput.Cursor]
+			gitignoreSelections = selectAllRepositories(gitRepositories)
+		}
 	}
 
 	// 4. Process
-	err = processInitialization(
-		config.TemplateDirectory,
-		processingContext.TemplateDirectoryContents,
-		repositoryFragmentContext.InputGitRepositories,
-		config.TargetFolder,
-		selectionPromptOutput.Selected,
-	)
+	if config.FlagDryRun {
+		err = printInitializationPlan(config, processingContext, *repositoryFragmentContext, templateSelections, gitignoreSelections, ignorePatterns, ignoreTarget)
+		if err != nil {
+			return nil, fmt.Errorf("error printing plan:\n%w", err)
+		}
+		return nil, nil
+	}
+	err = processInitialization(config, processingContext, *repositoryFragmentContext, templateSelections)
+	if errors.Is(err, errConflictResolutionAborted) {
+		return &InitializationResult{ShouldExit: true}, nil
+	}
+	// Ignore files are still processed, as all files were written
+	var mergeConflictsErr error
+	if errors.Is(err, errMergeConflicts) {
+		mergeConflictsErr, err = err, nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("repository initialization error:\n%w", err)
 	}
-	if shouldInitializeGitignore {
-		processGitignore(repositoryFragmentContext.InputGitRepositories, config.TargetFolder, repositoryFragmentContext.GitignorePresence)
-		if err != nil {
-			return nil, fmt.Errorf("gitignore initialization error:\n%w", err)
-		}
+	err = processGitignore(config, processingContext, *repositoryFragmentContext, gitignoreSelections, ignorePatterns, ignoreTarget)
+	if err != nil {
+		return nil, fmt.Errorf("gitignore initialization error:\n%w", err)
+	}
+	if mergeConflictsErr != nil {
+		return nil, fmt.Errorf("resolve conflict markers and re-run:\n%w", mergeConflictsErr)
 	}
 	return nil, nil
 }
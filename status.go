@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/koniferous22/dot-user-git-util/utils"
+)
+
+type RepositoryStatus struct {
+	Repository    string   `json:"repository"`
+	TargetFolder  string   `json:"targetFolder"`
+	TargetPresent bool     `json:"targetPresent"`
+	Gitignored    bool     `json:"gitignored"`
+	IgnoredBy     string   `json:"ignoredBy,omitempty"`
+	Installed     []string `json:"installed"`
+	Outdated      []string `json:"outdated"`
+	Modified      []string `json:"modified"`
+	Differs       []string `json:"differs"`
+	Missing       []string `json:"missing"`
+	Unknown       []string `json:"unknown"`
+}
+
+// Files in target directory that don't originate from any template entry, local copies moved aside on conflicts aren't reported
+func resolveUnknownTargetFiles(config Config, processingContext ProcessingContext, gitRepository string) ([]string, error) {
+	unknownFiles := make([]string, 0)
+	targetPath := filepath.Join(gitRepository, config.TargetFolder)
+	if present, err := checkTargetDirectoryPresent(gitRepository, config.TargetFolder); err != nil || !present {
+		return unknownFiles, err
+	}
+	contents, err := utils.ListDirectoryContents(targetPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, targetDirectoryEntry := range contents {
+		if strings.HasSuffix(targetDirectoryEntry, string(filepath.Separator)) || targetDirectoryEntry == InstallManifestFileName {
+			continue
+		}
+		if backedUpEntry, ok := strings.CutSuffix(targetDirectoryEntry, ConflictOriginalSuffix); ok && slices.Contains(processingContext.TemplateDirectoryTargetNames, backedUpEntry) {
+			continue
+		}
+		if !slices.Contains(processingContext.TemplateDirectoryTargetNames, targetDirectoryEntry) {
+			unknownFiles = append(unknownFiles, targetDirectoryEntry)
+		}
+	}
+	return unknownFiles, nil
+}
+
+func resolveRepositoryStatuses(config Config, processingContext ProcessingContext, gitRepositories []string) ([]RepositoryStatus, error) {
+	repositoryFragmentContext, err := initializeRepositorySequenceContext(config, processingContext, gitRepositories)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing repository fragment context\n%w", err)
+	}
+	repositoryStatuses := make([]RepositoryStatus, len(gitRepositories))
+	for j, gitRepository := range gitRepositories {
+		unknownFiles, err := resolveUnknownTargetFiles(config, processingContext, gitRepository)
+		if err != nil {
+			return nil, fmt.Errorf("error listing target directory in %q:\n%w", gitRepository, err)
+		}
+		repositoryStatus := RepositoryStatus{
+			Repository:    gitRepository,
+			TargetFolder:  config.TargetFolder,
+			TargetPresent: repositoryFragmentContext.TargetDirectoryPresence[j],
+			Gitignored:    repositoryFragmentContext.GitignorePresence[j],
+			IgnoredBy:     repositoryFragmentContext.GitignoreSources[j],
+			Installed:     make([]string, 0),
+			Outdated:      make([]string, 0),
+			Modified:      make([]string, 0),
+			Differs:       make([]string, 0),
+			Missing:       make([]string, 0),
+			Unknown:       unknownFiles,
+		}
+		for i, entryStatuses := range repositoryFragmentContext.TemplateDirectoryStatuses {
+			entryIdentifier := processingContext.GetTemplateEntryIdentifier(i)
+			switch entryStatuses[j] {
+			case TemplateEntryStatusIdentical:
+				repositoryStatus.Installed = append(repositoryStatus.Installed, entryIdentifier)
+			case TemplateEntryStatusOutdated:
+				repositoryStatus.Outdated = append(repositoryStatus.Outdated, entryIdentifier)
+			case TemplateEntryStatusModified:
+				repositoryStatus.Modified = append(repositoryStatus.Modified, entryIdentifier)
+			case TemplateEntryStatusDiffers:
+				repositoryStatus.Differs = append(repositoryStatus.Differs, entryIdentifier)
+			default:
+				repositoryStatus.Missing = append(repositoryStatus.Missing, entryIdentifier)
+			}
+		}
+		repositoryStatuses[j] = repositoryStatus
+	}
+	return repositoryStatuses, nil
+}
+
+type statusTableCell struct {
+	text  string
+	color string
+}
+
+func formatStatusCount(entries []string, color string) statusTableCell {
+	if len(entries) == 0 {
+		return statusTableCell{text: "-"}
+	}
+	return statusTableCell{text: fmt.Sprintf("%d", len(entries)), color: color}
+}
+
+func formatStatusFlag(value bool, trueColor string, falseColor string) statusTableCell {
+	if value {
+		return statusTableCell{text: "yes", color: trueColor}
+	}
+	return statusTableCell{text: "no", color: falseColor}
+}
+
+// Columns are padded by visible text, so that colors don't break the alignment
+func printStatusTable(repositoryStatuses []RepositoryStatus) {
+	header := []string{"REPOSITORY", "TARGET", "IGNORED", "INSTALLED", "OUTDATED", "MODIFIED", "DIFFERS", "MISSING", "UNKNOWN"}
+	rows := make([][]statusTableCell, len(repositoryStatuses))
+	for i, repositoryStatus := range repositoryStatuses {
+		rows[i] = []statusTableCell{
+			{text: repositoryStatus.Repository, color: utils.FontBold},
+			formatStatusFlag(repositoryStatus.TargetPresent, utils.ColorGreen, utils.ColorRed),
+			formatStatusFlag(repositoryStatus.Gitignored, utils.ColorGreen, utils.ColorYellow),
+			formatStatusCount(repositoryStatus.Installed, utils.ColorGreen),
+			formatStatusCount(repositoryStatus.Outdated, utils.ColorYellow),
+			formatStatusCount(repositoryStatus.Modified, utils.ColorPurple),
+			formatStatusCount(repositoryStatus.Differs, utils.ColorCyan),
+			formatStatusCount(repositoryStatus.Missing, utils.ColorBlue),
+			formatStatusCount(repositoryStatus.Unknown, utils.ColorRed),
+		}
+	}
+	columnWidths := make([]int, len(header))
+	for i, title := range header {
+		columnWidths[i] = len(title)
+		for _, row := range rows {
+			columnWidths[i] = max(columnWidths[i], len(row[i].text))
+		}
+	}
+	line := ""
+	for i, title := range header {
+		line += fmt.Sprintf("%-*s  ", columnWidths[i], title)
+	}
+	fmt.Printf("%s%s%s\n", utils.FontBold, strings.TrimRight(line, " "), utils.Reset)
+	for _, row := range rows {
+		line = ""
+		for i, cell := range row {
+			padding := strings.Repeat(" ", columnWidths[i]-len(cell.text)+2)
+			if cell.color == "" {
+				line += cell.text + padding
+			} else {
+				line += cell.color + cell.text + utils.Reset + padding
+			}
+		}
+		fmt.Println(strings.TrimRight(line, " "))
+	}
+	for _, repositoryStatus := range repositoryStatuses {
+		details := ""
+		if repositoryStatus.IgnoredBy != "" {
+			details += fmt.Sprintf("  %signored by%s: %s\n", utils.ColorGreen, utils.Reset, repositoryStatus.IgnoredBy)
+		}
+		for _, category := range []struct {
+			name    string
+			color   string
+			entries []string
+		}{
+			{"outdated", utils.ColorYellow, repositoryStatus.Outdated},
+			{"modified", utils.ColorPurple, repositoryStatus.Modified},
+			{"differs", utils.ColorCyan, repositoryStatus.Differs},
+			{"unknown", utils.ColorRed, repositoryStatus.Unknown},
+		} {
+			if len(category.entries) > 0 {
+				details += fmt.Sprintf("  %s%s%s: %s\n", category.color, category.name, utils.Reset, strings.Join(category.entries, ", "))
+			}
+		}
+		if details != "" {
+			fmt.Printf("\n%s%s%s\n%s", utils.FontBold, repositoryStatus.Repository, utils.Reset, details)
+		}
+	}
+}
+
+func RunStatusOnRepositories(config Config, processingContext ProcessingContext, gitRepositories []string) error {
+	repositoryStatuses, err := resolveRepositoryStatuses(config, processingContext, gitRepositories)
+	if err != nil {
+		return err
+	}
+	if config.FlagJsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(repositoryStatuses)
+	}
+	printStatusTable(repositoryStatuses)
+	return nil
+}
@@ -29,7 +29,8 @@ func GetTargetDirectoryPresence(gitRepositoryPaths []string, targetFolder string
 	return &result, nil
 }
 
-func CheckExecutableInTargetDirectories(gitRepositoryPaths []string, targetFolder string, targetName string) (*[]bool, error) {
+// Without "executablesOnly", entries are compared by presence only
+func CheckEntryInTargetDirectories(gitRepositoryPaths []string, targetFolder string, targetName string, executablesOnly bool) (*[]bool, error) {
 	result := make([]bool, len(gitRepositoryPaths))
 	var errors []error
 	for i, gitRepositoryPath := range gitRepositoryPaths {
@@ -39,8 +40,12 @@ func CheckExecutableInTargetDirectories(gitRepositoryPaths []string, targetFolde
 			result[i] = false
 			continue
 		}
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
 		if targetFileInfo.Mode().IsRegular() {
-			if targetFileInfo.Mode()&0111 != 0 {
+			if !executablesOnly || targetFileInfo.Mode()&0111 != 0 {
 				result[i] = true
 			} else {
 				errors = append(errors, fmt.Errorf("no exec permissions on %q", targetPath))
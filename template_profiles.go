@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/koniferous22/dot-user-git-util/utils"
+)
+
+const (
+	ProfileCollisionError = "error"
+	ProfileCollisionFirst = "first"
+	ProfileCollisionLast  = "last"
+)
+
+// Separates profile name from template directory, e.g. "go=/path/to/go-templates"
+const TemplateProfileNameSeparator = "="
+
+// Qualifies template entries, when multiple profiles are configured, e.g. "go:lint.sh"
+const TemplateProfileEntrySeparator = ":"
+
+type TemplateProfile struct {
+	Name      string
+	Directory string
+}
+
+// Profile names consist of letters, digits, "-", "_" and ".", so that paths containing "=" aren't mistaken for named profiles
+var templateProfileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+func isTemplateProfileName(name string) bool {
+	return templateProfileNamePattern.MatchString(name)
+}
+
+// Template directories are separated by ":" and optionally named with "<name>=<directory>", otherwise directory name is used
+func ParseTemplateProfiles(templateDirectories string) ([]TemplateProfile, error) {
+	var profiles []TemplateProfile
+	profileNames := make(map[string]bool)
+	for _, templateDirectory := range filepath.SplitList(templateDirectories) {
+		if templateDirectory == "" {
+			continue
+		}
+		profile := TemplateProfile{Name: filepath.Base(templateDirectory), Directory: templateDirectory}
+		if name, directory, ok := strings.Cut(templateDirectory, TemplateProfileNameSeparator); ok && isTemplateProfileName(name) {
+			// Existing directory containing "=" in its path isn't split
+			if isDirectory, _ := utils.ValidateDirectoryExists(templateDirectory); !isDirectory {
+				profile = TemplateProfile{Name: name, Directory: directory}
+			}
+		}
+		if profile.Name == "" || strings.Contains(profile.Name, TemplateProfileEntrySeparator) {
+			return nil, fmt.Errorf("invalid template profile name %q", profile.Name)
+		}
+		if profileNames[profile.Name] {
+			return nil, fmt.Errorf("duplicate template profile name %q", profile.Name)
+		}
+		profileNames[profile.Name] = true
+		profiles = append(profiles, profile)
+	}
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("no template directory configured")
+	}
+	return profiles, nil
+}
+
+// Collects repeated "--template-dir" occurrences, first occurrence overrides value from env variable
+type templateDirectoriesValue struct {
+	templateDirectories *string
+	isSet               bool
+}
+
+func (v *templateDirectoriesValue) String() string {
+	return *v.templateDirectories
+}
+
+func (v *templateDirectoriesValue) Set(value string) error {
+	if !v.isSet {
+		*v.templateDirectories = value
+		v.isSet = true
+		return nil
+	}
+	*v.templateDirectories += string(filepath.ListSeparator) + value
+	return nil
+}
+
+type templateProfileEntry struct {
+	name       string
+	profile    string
+	targetName string
+}
+
+// Lists entries of all profiles, resolving entries with the same target name according to collision policy
+func listTemplateProfileEntries(config Config, profiles []TemplateProfile) ([]templateProfileEntry, error) {
+	listTemplateDirectory := utils.ListTopLevelFilesInDirectory
+	if config.FlagRecursiveTemplate {
+		listTemplateDirectory = utils.ListFilesInDirectoryRecursively
+	}
+	var entries []templateProfileEntry
+	entryIndexByTargetName := make(map[string]int)
+	overriddenEntries := make(map[int]bool)
+	var collisions []error
+	for _, profile := range profiles {
+		profileEntries, err := listTemplateDirectory(profile.Directory, !config.FlagIncludeNonExecutable)
+		if err != nil {
+			return nil, fmt.Errorf("error listing files in template directory %q - %w", profile.Directory, err)
+		}
+		for _, profileEntry := range profileEntries {
+			if profileEntry == TemplateRulesFileName || profileEntry == TemplateVisibilityFileName {
+				continue
+			}
+			entry := templateProfileEntry{
+				name:       profileEntry,
+				profile:    profile.Name,
+				targetName: GetTemplateEntryTargetName(config, profileEntry),
+			}
+			existingIndex, ok := entryIndexByTargetName[entry.targetName]
+			if !ok {
+				entryIndexByTargetName[entry.targetName] = len(entries)
+				entries = append(entries, entry)
+				continue
+			}
+			existingEntry := entries[existingIndex]
+			switch {
+			case existingEntry.profile == entry.profile:
+				return nil, fmt.Errorf("template entries %q and %q resolve to the same target %q", existingEntry.name, entry.name, entry.targetName)
+			case config.ProfileCollision == ProfileCollisionLast:
+				overriddenEntries[existingIndex] = true
+				entryIndexByTargetName[entry.targetName] = len(entries)
+				entries = append(entries, entry)
+			case config.ProfileCollision != ProfileCollisionFirst:
+				collisions = append(collisions, fmt.Errorf(
+					"%q provided by both %q and %q",
+					entry.targetName,
+					existingEntry.profile+TemplateProfileEntrySeparator+existingEntry.name,
+					entry.profile+TemplateProfileEntrySeparator+entry.name,
+				))
+			}
+		}
+	}
+	if len(collisions) > 0 {
+		return nil, fmt.Errorf(
+			"template profile collisions, pick precedence with \"--profile-collision=%s|%s\"\n%w",
+			ProfileCollisionFirst,
+			ProfileCollisionLast,
+			utils.AggregateErrors(collisions),
+		)
+	}
+	var result []templateProfileEntry
+	for i, entry := range entries {
+		if !overriddenEntries[i] {
+			result = append(result, entry)
+		}
+	}
+	return result, nil
+}
+
+func (processingContext ProcessingContext) GetTemplateEntrySourceFile(i int) string {
+	for _, profile := range processingContext.TemplateProfiles {
+		if profile.Name == processingContext.TemplateDirectoryProfiles[i] {
+			return filepath.Join(profile.Directory, processingContext.TemplateDirectoryContents[i])
+		}
+	}
+	return ""
+}
+
+// Entries are qualified by profile name only when multiple profiles are configured
+func (processingContext ProcessingContext) GetTemplateEntryIdentifier(i int) string {
+	if len(processingContext.TemplateProfiles) > 1 {
+		return processingContext.TemplateDirectoryProfiles[i] + TemplateProfileEntrySeparator + processingContext.TemplateDirectoryContents[i]
+	}
+	return processingContext.TemplateDirectoryContents[i]
+}
+
+func (processingContext ProcessingContext) GetTemplateEntryIdentifiers() []string {
+	identifiers := make([]string, len(processingContext.TemplateDirectoryContents))
+	for i := range identifiers {
+		identifiers[i] = processingContext.GetTemplateEntryIdentifier(i)
+	}
+	return identifiers
+}
+
+// Resolves entry by its identifier, or by its name when it's unique across profiles
+func (processingContext ProcessingContext) FindTemplateEntry(identifier string) (int, error) {
+	entryIndex := -1
+	for i, templateDirectoryEntry := range processingContext.TemplateDirectoryContents {
+		if processingContext.GetTemplateEntryIdentifier(i) == identifier {
+			return i, nil
+		}
+		if templateDirectoryEntry == identifier {
+			if entryIndex >= 0 {
+				return -1, fmt.Errorf("ambiguous template entry %q, qualify it with profile name, e.g. %q", identifier, processingContext.GetTemplateEntryIdentifier(i))
+			}
+			entryIndex = i
+		}
+	}
+	return entryIndex, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+const RenderedTemplateSuffix = ".tmpl"
+
+// Values available in rendered template files, e.g. {{ .RepositoryName }}
+type RepositoryTemplateData struct {
+	RepositoryName string
+	RepositoryPath string
+	DefaultBranch  string
+	RemoteURL      string
+	TargetFolder   string
+}
+
+func ShouldRenderTemplateEntry(config Config, templateDirectoryEntry string) bool {
+	return config.FlagRenderTemplates && strings.HasSuffix(templateDirectoryEntry, RenderedTemplateSuffix)
+}
+
+// Relative path of template directory entry in target directory
+func GetTemplateEntryTargetName(config Config, templateDirectoryEntry string) string {
+	if ShouldRenderTemplateEntry(config, templateDirectoryEntry) {
+		return strings.TrimSuffix(templateDirectoryEntry, RenderedTemplateSuffix)
+	}
+	return templateDirectoryEntry
+}
+
+// Git lookups are best-effort, missing remote or git binary results in empty values
+func runGitQuery(gitRepositoryPath string, args ...string) string {
+	output, err := exec.Command("git", append([]string{"-C", gitRepositoryPath}, args...)...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func hasRenderedTemplateEntries(config Config, processingContext ProcessingContext) bool {
+	for _, templateDirectoryEntry := range processingContext.TemplateDirectoryContents {
+		if ShouldRenderTemplateEntry(config, templateDirectoryEntry) {
+			return true
+		}
+	}
+	return false
+}
+
+// Git lookups are skipped, when no entry is rendered
+func resolveRepositoryTemplateData(config Config, processingContext ProcessingContext, gitRepositoryPath string) RepositoryTemplateData {
+	if !hasRenderedTemplateEntries(config, processingContext) {
+		return RepositoryTemplateData{}
+	}
+	defaultBranch := strings.TrimPrefix(runGitQuery(gitRepositoryPath, "symbolic-ref", "--short", "refs/remotes/origin/HEAD"), "origin/")
+	if defaultBranch == "" {
+		defaultBranch = runGitQuery(gitRepositoryPath, "symbolic-ref", "--short", "HEAD")
+	}
+	return RepositoryTemplateData{
+		RepositoryName: filepath.Base(gitRepositoryPath),
+		RepositoryPath: gitRepositoryPath,
+		DefaultBranch:  defaultBranch,
+		RemoteURL:      runGitQuery(gitRepositoryPath, "config", "--get", "remote.origin.url"),
+		TargetFolder:   config.TargetFolder,
+	}
+}
+
+func renderTemplateFile(sourceFile string, data RepositoryTemplateData) ([]byte, error) {
+	contents, err := os.ReadFile(sourceFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading template file %q:\n%w", sourceFile, err)
+	}
+	fileTemplate, err := template.New(filepath.Base(sourceFile)).Option("missingkey=error").Parse(string(contents))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template file %q:\n%w", sourceFile, err)
+	}
+	var rendered bytes.Buffer
+	if err := fileTemplate.Execute(&rendered, data); err != nil {
+		return nil, fmt.Errorf("error rendering template file %q for %q:\n%w", sourceFile, data.RepositoryPath, err)
+	}
+	return rendered.Bytes(), nil
+}
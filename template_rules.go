@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Optional file in template directory, mapping repository markers to template entries, e.g.
+//
+//	# <marker glob> [<marker glob>...]: <entry> [<entry>...]
+//	go.mod: lint.sh test.sh
+//	package.json yarn.lock: node-clean.sh
+const TemplateRulesFileName = ".dot-user-git-util-rules"
+
+type TemplateRule struct {
+	// Globs relative to repository root, rule applies when any of them matches
+	Markers []string
+	// Indices of "ProcessingContext.TemplateDirectoryContents"
+	EntryIndices []int
+}
+
+type templateRuleDefinition struct {
+	markers []string
+	entries []string
+}
+
+func parseTemplateRulesFile(rulesFilePath string) ([]templateRuleDefinition, error) {
+	file, err := os.Open(rulesFilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var rules []templateRuleDefinition
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		markers, entries, ok := strings.Cut(line, ":")
+		if !ok || len(strings.Fields(markers)) == 0 || len(strings.Fields(entries)) == 0 {
+			return nil, fmt.Errorf("%s:%d: expected \"<marker glob>...: <entry>...\"", rulesFilePath, lineNumber)
+		}
+		for _, marker := range strings.Fields(markers) {
+			if _, err := filepath.Match(marker, ""); err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid marker glob %q", rulesFilePath, lineNumber, marker)
+			}
+		}
+		rules = append(rules, templateRuleDefinition{markers: strings.Fields(markers), entries: strings.Fields(entries)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Loads rules files of all profiles, entries are resolved within the profile of the rules file
+func loadTemplateRules(processingContext ProcessingContext) ([]TemplateRule, error) {
+	var templateRules []TemplateRule
+	for _, profile := range processingContext.TemplateProfiles {
+		rulesFilePath := filepath.Join(profile.Directory, TemplateRulesFileName)
+		rules, err := parseTemplateRulesFile(rulesFilePath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error parsing template rules file %q:\n%w", rulesFilePath, err)
+		}
+		for _, rule := range rules {
+			templateRule := TemplateRule{Markers: rule.markers}
+			for _, entry := range rule.entries {
+				entryIndex := -1
+				for i, templateDirectoryEntry := range processingContext.TemplateDirectoryContents {
+					if templateDirectoryEntry == entry && processingContext.TemplateDirectoryProfiles[i] == profile.Name {
+						entryIndex = i
+					}
+				}
+				if entryIndex < 0 {
+					// Entry can be present, but not listed (e.g. nested or non-executable without respective flags)
+					if _, err := os.Stat(filepath.Join(profile.Directory, entry)); err == nil {
+						continue
+					}
+					return nil, fmt.Errorf("template rules file %q references unknown entry %q", rulesFilePath, entry)
+				}
+				templateRule.EntryIndices = append(templateRule.EntryIndices, entryIndex)
+			}
+			templateRules = append(templateRules, templateRule)
+		}
+	}
+	return templateRules, nil
+}
+
+func checkMarkersInRepository(gitRepositoryPath string, markers []string) (bool, error) {
+	for _, marker := range markers {
+		matches, err := filepath.Glob(filepath.Join(gitRepositoryPath, marker))
+		if err != nil {
+			return false, err
+		}
+		if len(matches) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Resolves which template directory entries are suggested by repository markers
+// Indexed by template directory entry, then by repository
+func resolveTemplateDetections(processingContext ProcessingContext, gitRepositories []string) (*[][]bool, error) {
+	result := make([][]bool, len(processingContext.TemplateDirectoryContents))
+	for i := range result {
+		result[i] = make([]bool, len(gitRepositories))
+	}
+	for _, templateRule := range processingContext.TemplateRules {
+		for j, gitRepository := range gitRepositories {
+			markersFound, err := checkMarkersInRepository(gitRepository, templateRule.Markers)
+			if err != nil {
+				return nil, err
+			}
+			if !markersFound {
+				continue
+			}
+			for _, entryIndex := range templateRule.EntryIndices {
+				result[entryIndex][j] = true
+			}
+		}
+	}
+	return &result, nil
+}
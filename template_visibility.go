@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/koniferous22/dot-user-git-util/utils"
+)
+
+// Optional file in template directory, marking entries as committed or ignored, e.g.
+//
+//	# <visibility>: <entry glob> [<entry glob>...]
+//	shared: lint.sh test.sh
+//	private: *.local.sh
+//
+// When found in any profile, ignore patterns are written per private entry instead of whole target directory
+// Later lines take precedence, entries without visibility are private
+const TemplateVisibilityFileName = ".dot-user-git-util-visibility"
+
+const (
+	// Ignored by per-entry pattern
+	TemplateVisibilityPrivate = "private"
+	// Left to be committed with the repository
+	TemplateVisibilityShared = "shared"
+)
+
+type templateVisibilityDefinition struct {
+	visibility string
+	globs      []string
+}
+
+func parseTemplateVisibilityFile(visibilityFilePath string) ([]templateVisibilityDefinition, error) {
+	file, err := os.Open(visibilityFilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var definitions []templateVisibilityDefinition
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		visibility, globs, ok := strings.Cut(line, ":")
+		visibility = strings.TrimSpace(visibility)
+		if !ok || len(strings.Fields(globs)) == 0 {
+			return nil, fmt.Errorf("%s:%d: expected \"<visibility>: <entry glob>...\"", visibilityFilePath, lineNumber)
+		}
+		if visibility != TemplateVisibilityPrivate && visibility != TemplateVisibilityShared {
+			return nil, fmt.Errorf("%s:%d: invalid visibility %q, expected %q or %q", visibilityFilePath, lineNumber, visibility, TemplateVisibilityPrivate, TemplateVisibilityShared)
+		}
+		for _, glob := range strings.Fields(globs) {
+			if _, err := path.Match(glob, ""); err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid entry glob %q", visibilityFilePath, lineNumber, glob)
+			}
+		}
+		definitions = append(definitions, templateVisibilityDefinition{visibility: visibility, globs: strings.Fields(globs)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return definitions, nil
+}
+
+// Loads visibility files of all profiles, globs are matched against entries of the profile of the visibility file
+// Empty when no visibility file is found, meaning whole target directory is ignored
+func loadTemplateVisibilities(processingContext ProcessingContext) ([]string, error) {
+	var result []string
+	for _, profile := range processingContext.TemplateProfiles {
+		visibilityFilePath := filepath.Join(profile.Directory, TemplateVisibilityFileName)
+		definitions, err := parseTemplateVisibilityFile(visibilityFilePath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error parsing template visibility file %q:\n%w", visibilityFilePath, err)
+		}
+		if result == nil {
+			result = make([]string, len(processingContext.TemplateDirectoryContents))
+			for i := range result {
+				result[i] = TemplateVisibilityPrivate
+			}
+		}
+		for _, definition := range definitions {
+			for i, templateDirectoryEntry := range processingContext.TemplateDirectoryContents {
+				if processingContext.TemplateDirectoryProfiles[i] != profile.Name {
+					continue
+				}
+				for _, glob := range definition.globs {
+					if matched, _ := path.Match(glob, filepath.ToSlash(templateDirectoryEntry)); matched {
+						result[i] = definition.visibility
+					}
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+func (processingContext ProcessingContext) HasTemplateVisibilities() bool {
+	return processingContext.TemplateDirectoryVisibilities != nil
+}
+
+// Patterns written into managed block - either whole target directory, or install manifest and target names of private entries
+func (processingContext ProcessingContext) GetIgnorePatterns(targetFolder string) []string {
+	if !processingContext.HasTemplateVisibilities() {
+		return []string{GetGitignorePattern(targetFolder)}
+	}
+	patterns := []string{GetGitignorePattern(targetFolder) + InstallManifestFileName}
+	for i, templateDirectoryTargetName := range processingContext.TemplateDirectoryTargetNames {
+		if processingContext.TemplateDirectoryVisibilities[i] == TemplateVisibilityPrivate {
+			patterns = append(patterns, GetGitignorePattern(targetFolder)+filepath.ToSlash(templateDirectoryTargetName))
+		}
+	}
+	return patterns
+}
+
+func formatTemplateVisibility(visibility string) string {
+	if visibility == TemplateVisibilityPrivate {
+		return fmt.Sprintf("%s%s%s", utils.ColorPurple, visibility, utils.Reset)
+	}
+	return fmt.Sprintf("%s%s%s", utils.ColorCyan, visibility, utils.Reset)
+}
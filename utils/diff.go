@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+type DiffOperationKind int
+
+const (
+	DiffEqual DiffOperationKind = iota
+	DiffDelete
+	DiffInsert
+)
+
+type DiffOperation struct {
+	Kind DiffOperationKind
+	Line string
+}
+
+func HashContents(contents []byte) string {
+	sum := sha256.Sum256(contents)
+	return hex.EncodeToString(sum[:])
+}
+
+// Splits contents into lines, keeping line endings, so that missing trailing newline is preserved
+func SplitLines(contents string) []string {
+	lines := strings.SplitAfter(contents, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Longest common subsequence line diff, common prefix and suffix are trimmed before building the table
+func DiffLines(a []string, b []string) []DiffOperation {
+	prefixLength := 0
+	for prefixLength < len(a) && prefixLength < len(b) && a[prefixLength] == b[prefixLength] {
+		prefixLength++
+	}
+	suffixLength := 0
+	for suffixLength < len(a)-prefixLength && suffixLength < len(b)-prefixLength && a[len(a)-1-suffixLength] == b[len(b)-1-suffixLength] {
+		suffixLength++
+	}
+	var operations []DiffOperation
+	for _, line := range a[:prefixLength] {
+		operations = append(operations, DiffOperation{Kind: DiffEqual, Line: line})
+	}
+	middleA, middleB := a[prefixLength:len(a)-suffixLength], b[prefixLength:len(b)-suffixLength]
+	table := make([][]int32, len(middleA)+1)
+	for i := range table {
+		table[i] = make([]int32, len(middleB)+1)
+	}
+	for i := len(middleA) - 1; i >= 0; i-- {
+		for j := len(middleB) - 1; j >= 0; j-- {
+			if middleA[i] == middleB[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(middleA) || j < len(middleB) {
+		switch {
+		case i < len(middleA) && j < len(middleB) && middleA[i] == middleB[j]:
+			operations = append(operations, DiffOperation{Kind: DiffEqual, Line: middleA[i]})
+			i++
+			j++
+		case i < len(middleA) && (j == len(middleB) || table[i+1][j] >= table[i][j+1]):
+			operations = append(operations, DiffOperation{Kind: DiffDelete, Line: middleA[i]})
+			i++
+		default:
+			operations = append(operations, DiffOperation{Kind: DiffInsert, Line: middleB[j]})
+			j++
+		}
+	}
+	for _, line := range a[len(a)-suffixLength:] {
+		operations = append(operations, DiffOperation{Kind: DiffEqual, Line: line})
+	}
+	return operations
+}
+
+func formatDiffLine(prefix string, line string) string {
+	if strings.HasSuffix(line, "\n") {
+		return prefix + line
+	}
+	return prefix + line + "\n\\ No newline at end of file\n"
+}
+
+// Unified diff with 3 lines of context, empty when contents are equal
+func UnifiedDiff(aName string, bName string, a string, b string) string {
+	const contextLength = 3
+	operations := DiffLines(SplitLines(a), SplitLines(b))
+	result := ""
+	for start := 0; start < len(operations); {
+		if operations[start].Kind == DiffEqual {
+			start++
+			continue
+		}
+		// Extend hunk until there's more than 2x context of unchanged lines
+		hunkStart := max(start-contextLength, 0)
+		hunkEnd := start
+		for equalRun := 0; hunkEnd < len(operations) && equalRun <= 2*contextLength; hunkEnd++ {
+			if operations[hunkEnd].Kind == DiffEqual {
+				equalRun++
+			} else {
+				equalRun = 0
+			}
+		}
+		for hunkEnd > start && operations[hunkEnd-1].Kind == DiffEqual {
+			hunkEnd--
+		}
+		hunkEnd = min(hunkEnd+contextLength, len(operations))
+
+		aLine, bLine := 1, 1
+		for _, operation := range operations[:hunkStart] {
+			if operation.Kind != DiffInsert {
+				aLine++
+			}
+			if operation.Kind != DiffDelete {
+				bLine++
+			}
+		}
+		aCount, bCount := 0, 0
+		hunk := ""
+		for _, operation := range operations[hunkStart:hunkEnd] {
+			switch operation.Kind {
+			case DiffEqual:
+				hunk += formatDiffLine(" ", operation.Line)
+				aCount++
+				bCount++
+			case DiffDelete:
+				hunk += formatDiffLine("-", operation.Line)
+				aCount++
+			case DiffInsert:
+				hunk += formatDiffLine("+", operation.Line)
+				bCount++
+			}
+		}
+		if aCount == 0 {
+			aLine--
+		}
+		if bCount == 0 {
+			bLine--
+		}
+		result += fmt.Sprintf("@@ -%d,%d +%d,%d @@\n%s", aLine, aCount, bLine, bCount, hunk)
+		start = hunkEnd
+	}
+	if result == "" {
+		return ""
+	}
+	return fmt.Sprintf("--- %s\n+++ %s\n%s", aName, bName, result)
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitLines(t *testing.T) {
+	testCases := []struct {
+		contents string
+		lines    []string
+	}{
+		{contents: "", lines: []string{}},
+		{contents: "a\nb\n", lines: []string{"a\n", "b\n"}},
+		{contents: "a\nb", lines: []string{"a\n", "b"}},
+		{contents: "a\r\n\n", lines: []string{"a\r\n", "\n"}},
+	}
+	for _, testCase := range testCases {
+		if lines := SplitLines(testCase.contents); !slices.Equal(lines, testCase.lines) {
+			t.Errorf("SplitLines(%q) = %q, want %q", testCase.contents, lines, testCase.lines)
+		}
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	operations := DiffLines([]string{"a\n", "b\n", "c\n", "d\n"}, []string{"a\n", "x\n", "c\n", "d\n", "e\n"})
+	expected := []DiffOperation{
+		{Kind: DiffEqual, Line: "a\n"},
+		{Kind: DiffDelete, Line: "b\n"},
+		{Kind: DiffInsert, Line: "x\n"},
+		{Kind: DiffEqual, Line: "c\n"},
+		{Kind: DiffEqual, Line: "d\n"},
+		{Kind: DiffInsert, Line: "e\n"},
+	}
+	if !slices.Equal(operations, expected) {
+		t.Errorf("DiffLines = %v, want %v", operations, expected)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	testCases := []struct {
+		name string
+		a    string
+		b    string
+		diff string
+	}{
+		{name: "equal", a: "a\nb\n", b: "a\nb\n", diff: ""},
+		{
+			name: "changed line",
+			a:    "1\n2\n3\n4\n5\n",
+			b:    "1\n2\nthree\n4\n5\n",
+			diff: "--- a\n+++ b\n@@ -1,5 +1,5 @@\n 1\n 2\n-3\n+three\n 4\n 5\n",
+		},
+		{
+			name: "insertion into empty file",
+			a:    "",
+			b:    "x\n",
+			diff: "--- a\n+++ b\n@@ -0,0 +1,1 @@\n+x\n",
+		},
+		{
+			name: "missing trailing newline",
+			a:    "x\n",
+			b:    "x",
+			diff: "--- a\n+++ b\n@@ -1,1 +1,1 @@\n-x\n+x\n\\ No newline at end of file\n",
+		},
+		{
+			name: "separate hunks",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			b:    "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n",
+			diff: "--- a\n+++ b\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+twelve\n",
+		},
+	}
+	for _, testCase := range testCases {
+		if diff := UnifiedDiff("a", "b", testCase.a, testCase.b); diff != testCase.diff {
+			t.Errorf("%s: UnifiedDiff = %q, want %q", testCase.name, diff, testCase.diff)
+		}
+	}
+}
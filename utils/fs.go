@@ -2,9 +2,10 @@ package utils
 
 import (
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 )
 
 func EnsureDirectoryExists(directoryPath string) error {
@@ -15,7 +16,14 @@ func EnsureDirectoryExists(directoryPath string) error {
 	return nil
 }
 
-func ListTopLevelExecutablesInDirectory(directoryPath string) ([]string, error) {
+func isListedFile(fileInfo os.FileInfo, executablesOnly bool) bool {
+	if !fileInfo.Mode().IsRegular() {
+		return false
+	}
+	return !executablesOnly || fileInfo.Mode()&0111 != 0
+}
+
+func ListTopLevelFilesInDirectory(directoryPath string, executablesOnly bool) ([]string, error) {
 	dir, err := os.Open(directoryPath)
 	if err != nil {
 		return nil, err
@@ -25,57 +33,126 @@ func ListTopLevelExecutablesInDirectory(directoryPath string) ([]string, error)
 	if err != nil {
 		return nil, err
 	}
-	executables := make([]string, 0)
+	files := make([]string, 0)
 	for _, fileInfo := range fileInfos {
-		if fileInfo.Mode().IsRegular() && fileInfo.Mode()&0111 != 0 {
-			executables = append(executables, fileInfo.Name())
+		if isListedFile(fileInfo, executablesOnly) {
+			files = append(files, fileInfo.Name())
 		}
 	}
-	return executables, nil
+	return files, nil
 }
 
-func CopyFile(src, dst string) error {
-	sourceFile, err := os.Open(src)
+// Lists files in nested directories as relative paths, grouped by their directory with top-level entries first
+func ListFilesInDirectoryRecursively(directoryPath string, executablesOnly bool) ([]string, error) {
+	files := make([]string, 0)
+	err := filepath.Walk(directoryPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if isListedFile(info, executablesOnly) {
+			relativePath, err := filepath.Rel(directoryPath, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, relativePath)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("error opening source file %q:\n%w", src, err)
+		return nil, err
 	}
-	defer sourceFile.Close()
+	slices.SortStableFunc(files, func(a, b string) int {
+		aDirectory, bDirectory := filepath.Dir(a), filepath.Dir(b)
+		if aDirectory == bDirectory {
+			return strings.Compare(a, b)
+		}
+		if aDirectory == "." {
+			return -1
+		}
+		if bDirectory == "." {
+			return 1
+		}
+		return strings.Compare(aDirectory, bDirectory)
+	})
+	return files, nil
+}
 
-	destinationFile, err := os.Create(dst)
-	if err != nil {
-		return fmt.Errorf("error creating destination file %q:\n%w", dst, err)
+// Removes empty directories starting from parent of "path" until "rootPath" (exclusive)
+func RemoveEmptyParentDirectories(path string, rootPath string) error {
+	for directoryPath := filepath.Dir(path); directoryPath != rootPath && strings.HasPrefix(directoryPath, rootPath); directoryPath = filepath.Dir(directoryPath) {
+		entries, err := os.ReadDir(directoryPath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("error reading directory %q:\n%w", directoryPath, err)
+		}
+		if len(entries) > 0 {
+			return nil
+		}
+		if err := os.Remove(directoryPath); err != nil {
+			return fmt.Errorf("error removing directory %q:\n%w", directoryPath, err)
+		}
 	}
-	defer destinationFile.Close()
-	_, err = io.Copy(destinationFile, sourceFile)
-	if err != nil {
-		return fmt.Errorf("error copying file contents from %q to %s:\n%w", src, dst, err)
+	return nil
+}
+
+// Writes contents with given mode, also applied to already existing destination
+func WriteFile(dst string, contents []byte, mode os.FileMode) error {
+	err := os.WriteFile(dst, contents, mode)
+	if os.IsPermission(err) {
+		// Read-only destination (e.g. copied from read-only source) is replaced
+		if removeErr := os.Remove(dst); removeErr == nil {
+			err = os.WriteFile(dst, contents, mode)
+		}
 	}
-	sourceInfo, err := os.Stat(src)
 	if err != nil {
-		return fmt.Errorf("error getting source file info %q:\n%s", src, err)
+		return fmt.Errorf("error writing file %q:\n%w", dst, err)
 	}
-	err = os.Chmod(dst, sourceInfo.Mode())
-	if err != nil {
-		return fmt.Errorf("error setting permissions for file %q:\n%s", dst, err)
+	if err := os.Chmod(dst, mode); err != nil {
+		return fmt.Errorf("error setting permissions for file %q:\n%w", dst, err)
 	}
 	return nil
 }
 
-func RemoveAllFilesInDirectory(directoryPath string) error {
+func ListDirectoryContents(directoryPath string) ([]string, error) {
+	contents := make([]string, 0)
 	err := filepath.Walk(directoryPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		if !info.IsDir() {
-			err := os.Remove(path)
-			if err != nil {
-				return fmt.Errorf("error removing file %q:\n%w", path, err)
-			}
+		if path == directoryPath {
+			return nil
 		}
+		relativePath, err := filepath.Rel(directoryPath, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			relativePath += string(filepath.Separator)
+		}
+		contents = append(contents, relativePath)
 		return nil
 	})
 	if err != nil {
-		return fmt.Errorf("error walking through directory %q:\n%w", directoryPath, err)
+		return nil, fmt.Errorf("error walking through directory %q:\n%w", directoryPath, err)
+	}
+	return contents, nil
+}
+
+func RemoveAllFilesInDirectory(directoryPath string) error {
+	entries, err := os.ReadDir(directoryPath)
+	if err != nil {
+		return fmt.Errorf("error reading directory %q:\n%w", directoryPath, err)
+	}
+	for _, entry := range entries {
+		entryPath := filepath.Join(directoryPath, entry.Name())
+		if err := os.RemoveAll(entryPath); err != nil {
+			return fmt.Errorf("error removing %q:\n%w", entryPath, err)
+		}
 	}
 	return nil
 }
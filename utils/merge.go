@@ -0,0 +1,122 @@
+package utils
+
+import "strings"
+
+const (
+	MergeConflictLocalMarker  = "<<<<<<< local\n"
+	MergeConflictSeparator    = "=======\n"
+	MergeConflictRemoteMarker = ">>>>>>> template\n"
+)
+
+// Replacement of base lines [BaseStart, BaseEnd) with Lines
+type diffHunk struct {
+	BaseStart int
+	BaseEnd   int
+	Lines     []string
+}
+
+func resolveDiffHunks(base []string, changed []string) []diffHunk {
+	var hunks []diffHunk
+	baseIndex := 0
+	var currentHunk *diffHunk
+	for _, operation := range DiffLines(base, changed) {
+		if operation.Kind == DiffEqual {
+			if currentHunk != nil {
+				hunks = append(hunks, *currentHunk)
+				currentHunk = nil
+			}
+			baseIndex++
+			continue
+		}
+		if currentHunk == nil {
+			currentHunk = &diffHunk{BaseStart: baseIndex, BaseEnd: baseIndex}
+		}
+		if operation.Kind == DiffDelete {
+			baseIndex++
+			currentHunk.BaseEnd = baseIndex
+		} else {
+			currentHunk.Lines = append(currentHunk.Lines, operation.Line)
+		}
+	}
+	if currentHunk != nil {
+		hunks = append(hunks, *currentHunk)
+	}
+	return hunks
+}
+
+// Applies hunks within base region [regionStart, regionEnd)
+func applyDiffHunks(base []string, regionStart int, regionEnd int, hunks []diffHunk) []string {
+	var result []string
+	position := regionStart
+	for _, hunk := range hunks {
+		result = append(result, base[position:hunk.BaseStart]...)
+		result = append(result, hunk.Lines...)
+		position = hunk.BaseEnd
+	}
+	return append(result, base[position:regionEnd]...)
+}
+
+func ensureTrailingNewline(lines []string) []string {
+	if len(lines) > 0 && !strings.HasSuffix(lines[len(lines)-1], "\n") {
+		lines[len(lines)-1] += "\n"
+	}
+	return lines
+}
+
+// Three-way line merge, overlapping (or adjacent) differing changes result in conflict markers
+func MergeThreeWay(base string, local string, remote string) (string, bool) {
+	baseLines := SplitLines(base)
+	localHunks := resolveDiffHunks(baseLines, SplitLines(local))
+	remoteHunks := resolveDiffHunks(baseLines, SplitLines(remote))
+	var result []string
+	hasConflicts := false
+	position := 0
+	localIndex, remoteIndex := 0, 0
+	for localIndex < len(localHunks) || remoteIndex < len(remoteHunks) {
+		clusterStart := len(baseLines)
+		if localIndex < len(localHunks) {
+			clusterStart = localHunks[localIndex].BaseStart
+		}
+		if remoteIndex < len(remoteHunks) {
+			clusterStart = min(clusterStart, remoteHunks[remoteIndex].BaseStart)
+		}
+		clusterEnd := clusterStart
+		var localCluster, remoteCluster []diffHunk
+		for {
+			if localIndex < len(localHunks) && localHunks[localIndex].BaseStart <= clusterEnd {
+				clusterEnd = max(clusterEnd, localHunks[localIndex].BaseEnd)
+				localCluster = append(localCluster, localHunks[localIndex])
+				localIndex++
+				continue
+			}
+			if remoteIndex < len(remoteHunks) && remoteHunks[remoteIndex].BaseStart <= clusterEnd {
+				clusterEnd = max(clusterEnd, remoteHunks[remoteIndex].BaseEnd)
+				remoteCluster = append(remoteCluster, remoteHunks[remoteIndex])
+				remoteIndex++
+				continue
+			}
+			break
+		}
+		result = append(result, baseLines[position:clusterStart]...)
+		localVersion := applyDiffHunks(baseLines, clusterStart, clusterEnd, localCluster)
+		remoteVersion := applyDiffHunks(baseLines, clusterStart, clusterEnd, remoteCluster)
+		switch {
+		case len(remoteCluster) == 0:
+			result = append(result, localVersion...)
+		case len(localCluster) == 0:
+			result = append(result, remoteVersion...)
+		case strings.Join(localVersion, "") == strings.Join(remoteVersion, ""):
+			result = append(result, localVersion...)
+		default:
+			hasConflicts = true
+			result = append(result, MergeConflictLocalMarker)
+			result = append(result, ensureTrailingNewline(localVersion)...)
+			result = append(result, MergeConflictSeparator)
+			result = append(result, ensureTrailingNewline(remoteVersion)...)
+			result = append(result, MergeConflictRemoteMarker)
+		}
+		position = clusterEnd
+	}
+	result = append(result, baseLines[position:]...)
+	return strings.Join(result, ""), hasConflicts
+}
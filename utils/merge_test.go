@@ -0,0 +1,72 @@
+package utils
+
+import "testing"
+
+func TestMergeThreeWay(t *testing.T) {
+	testCases := []struct {
+		name         string
+		base         string
+		local        string
+		remote       string
+		merged       string
+		hasConflicts bool
+	}{
+		{
+			name:   "unchanged",
+			base:   "a\nb\n",
+			local:  "a\nb\n",
+			remote: "a\nb\n",
+			merged: "a\nb\n",
+		},
+		{
+			name:   "local change only",
+			base:   "a\nb\nc\n",
+			local:  "a\nB\nc\n",
+			remote: "a\nb\nc\n",
+			merged: "a\nB\nc\n",
+		},
+		{
+			name:   "remote change only",
+			base:   "a\nb\nc\n",
+			local:  "a\nb\nc\n",
+			remote: "a\nb\nC\n",
+			merged: "a\nb\nC\n",
+		},
+		{
+			name:   "non-overlapping changes",
+			base:   "1\n2\n3\n4\n5\n",
+			local:  "one\n2\n3\n4\n5\n",
+			remote: "1\n2\n3\n4\nfive\n",
+			merged: "one\n2\n3\n4\nfive\n",
+		},
+		{
+			name:   "identical changes",
+			base:   "a\nb\n",
+			local:  "a\nx\n",
+			remote: "a\nx\n",
+			merged: "a\nx\n",
+		},
+		{
+			name:         "conflicting changes",
+			base:         "a\nb\nc\n",
+			local:        "a\nlocal\nc\n",
+			remote:       "a\nremote\nc\n",
+			merged:       "a\n" + MergeConflictLocalMarker + "local\n" + MergeConflictSeparator + "remote\n" + MergeConflictRemoteMarker + "c\n",
+			hasConflicts: true,
+		},
+		{
+			name:         "conflict without trailing newline",
+			base:         "a\nb",
+			local:        "a\nlocal",
+			remote:       "a\nremote",
+			merged:       "a\n" + MergeConflictLocalMarker + "local\n" + MergeConflictSeparator + "remote\n" + MergeConflictRemoteMarker,
+			hasConflicts: true,
+		},
+	}
+	for _, testCase := range testCases {
+		merged, hasConflicts := MergeThreeWay(testCase.base, testCase.local, testCase.remote)
+		if merged != testCase.merged || hasConflicts != testCase.hasConflicts {
+			t.Errorf("%s: MergeThreeWay = %q, %v, want %q, %v", testCase.name, merged, hasConflicts, testCase.merged, testCase.hasConflicts)
+		}
+	}
+}